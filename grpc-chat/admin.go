@@ -0,0 +1,158 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// 1秒あたりの件数を平均する期間(秒)
+const rateWindow = 60
+
+// 直近rateWindow秒間の件数を1秒ごとに数えるカウンタ
+type rateMeter struct {
+	counts [rateWindow]uint64
+	// counts[i]を数えている時刻(Unix秒)、古いものは次に使うときにリセットする
+	secs [rateWindow]int64
+	mtx  sync.Mutex
+}
+
+// nowの1秒にn件を加えるメソッド
+func (m *rateMeter) Add(now time.Time, n uint64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	sec := now.Unix()
+	i := sec % rateWindow
+	if m.secs[i] != sec {
+		m.secs[i], m.counts[i] = sec, 0
+	}
+	m.counts[i] += n
+}
+
+// nowまでの直近rateWindow秒間の1秒あたりの平均を返すメソッド
+func (m *rateMeter) Rate(now time.Time) float64 {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	sec := now.Unix()
+	var total uint64
+	for i, s := range m.secs {
+		if sec-s < rateWindow {
+			total += m.counts[i]
+		}
+	}
+	return float64(total) / rateWindow
+}
+
+// Adminサービスの実装、Chatサービスのserverとはメソッド名(Broadcast)が重なるので別の型にする
+type admin struct {
+	s *server
+	chat.UnimplementedAdminServer
+}
+
+// 呼び出したセッションがモデレータであることを確認するメソッド
+func (a *admin) authorize(ctx context.Context) error {
+	if !a.s.Moderators[sessionFrom(ctx).Username] {
+		return status.Error(codes.PermissionDenied, "admin RPCs can only be used by a moderator")
+	}
+	return nil
+}
+
+// 接続数やメッセージの流量などの統計を返すメソッド
+func (a *admin) Stats(ctx context.Context, _ *chat.StatsRequest) (*chat.StatsResponse, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	s := a.s
+
+	s.namesMtx.RLock()
+	loggedIn := len(s.ClientNames)
+	s.namesMtx.RUnlock()
+	s.streamsMtx.RLock()
+	connected := len(s.ClientStreams)
+	s.streamsMtx.RUnlock()
+	s.roomsMtx.RLock()
+	rooms := len(s.Rooms)
+	s.roomsMtx.RUnlock()
+
+	return &chat.StatsResponse{
+		LoggedIn:          int32(loggedIn),
+		Connected:         int32(connected),
+		Rooms:             int32(rooms),
+		MessagesTotal:     s.msgTotal.Load(),
+		MessagesPerSecond: s.msgRate.Rate(time.Now()),
+		DroppedTotal:      s.droppedTotal.Load(),
+		Draining:          s.draining.Load(),
+		StartedAt:         timestamppb.New(s.started),
+	}, nil
+}
+
+// 全員、またはルームの参加者にお知らせを配信するメソッド
+func (a *admin) Broadcast(ctx context.Context, req *chat.BroadcastRequest) (*chat.BroadcastResponse, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		return nil, status.Error(codes.InvalidArgument, "message cannot be empty")
+	}
+
+	room := req.Room
+	if room != "" {
+		room = normalizeRoom(room)
+	}
+	from := sessionFrom(ctx).Username
+
+	a.s.Broadcast <- &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_ServerAnnouncement{
+			ServerAnnouncement: &chat.StreamResponse_Announcement{Message: req.Message, From: from, Room: room},
+		},
+	}
+	a.s.Log.Info("announced", "name", from, "room", room, "message", req.Message)
+
+	return new(chat.BroadcastResponse), nil
+}
+
+// 新しいログインの受付を止める、または再開するメソッド
+func (a *admin) Drain(ctx context.Context, req *chat.DrainRequest) (*chat.DrainResponse, error) {
+	if err := a.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	a.s.drain(!req.Resume)
+	a.s.Log.Info("changed drain state", "name", sessionFrom(ctx).Username, "draining", !req.Resume)
+
+	a.s.namesMtx.RLock()
+	defer a.s.namesMtx.RUnlock()
+	return &chat.DrainResponse{LoggedIn: int32(len(a.s.ClientNames))}, nil
+}
+
+// ログインの受付を止める、または再開するメソッド、止めている間はロードバランサが新しい接続を振り分けないようヘルスチェックも失敗させる
+func (s *server) drain(on bool) {
+	s.draining.Store(on)
+
+	st := healthpb.HealthCheckResponse_SERVING
+	if on {
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.Health.SetServingStatus("", st)
+	s.Health.SetServingStatus(chat.Chat_ServiceDesc.ServiceName, st)
+}
+
+// 統計のために配信したメッセージを数えるメソッド
+func (s *server) countMessage(res *chat.StreamResponse) {
+	switch res.Event.(type) {
+	case *chat.StreamResponse_ClientMessage, *chat.StreamResponse_DirectMessage:
+		s.msgTotal.Add(1)
+		s.msgRate.Add(time.Now(), 1)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateMeter(t *testing.T) {
+	var m rateMeter
+	now := time.Unix(1000, 0)
+
+	m.Add(now, 30)
+	m.Add(now.Add(time.Second), 30)
+	require.Equal(t, 1.0, m.Rate(now.Add(time.Second)))
+
+	// 期間を過ぎた件数は数えない
+	require.Equal(t, 0.5, m.Rate(now.Add(rateWindow*time.Second)))
+	m.Add(now.Add(rateWindow*time.Second), 6)
+	require.Equal(t, 0.6, m.Rate(now.Add(rateWindow*time.Second)))
+}
+
+func TestHealthAndReflection(t *testing.T) {
+	ts := startServer(t)
+	conn := ts.conn(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// ヘルスチェックはトークンなしで呼び出せる
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: chat.Chat_ServiceDesc.ServiceName})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	info, err := stream.Recv()
+	require.NoError(t, err)
+	var services []string
+	for _, svc := range info.GetListServicesResponse().GetService() {
+		services = append(services, svc.Name)
+	}
+	require.Contains(t, services, chat.Chat_ServiceDesc.ServiceName)
+	require.Contains(t, services, chat.Admin_ServiceDesc.ServiceName)
+	require.Contains(t, services, healthpb.Health_ServiceDesc.ServiceName)
+}
+
+func TestAdmin(t *testing.T) {
+	ts := startServer(t, func(s *server) { s.Moderators["mod"] = true })
+	mod := ts.startClient(t, "mod")
+	alice := ts.startClient(t, "alice")
+
+	adm := chat.NewAdminClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	modCtx := outgoingContext(ctx, mod.token(), 0)
+
+	// モデレータ以外は呼び出せない
+	_, err := adm.Stats(outgoingContext(ctx, alice.token(), 0), new(chat.StatsRequest))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = adm.Stats(ctx, new(chat.StatsRequest))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	alice.say(t, "hello")
+	mod.expect(t, "alice's message", messageFrom("alice", "hello"))
+	stats, err := adm.Stats(modCtx, new(chat.StatsRequest))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), stats.LoggedIn)
+	require.Equal(t, int32(2), stats.Connected)
+	require.Equal(t, uint64(1), stats.MessagesTotal)
+	require.Greater(t, stats.MessagesPerSecond, 0.0)
+	require.False(t, stats.Draining)
+	require.WithinDuration(t, time.Now(), stats.StartedAt.AsTime(), testTimeout)
+
+	// お知らせは全員に配信される
+	_, err = adm.Broadcast(modCtx, &chat.BroadcastRequest{Message: " "})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = adm.Broadcast(modCtx, &chat.BroadcastRequest{Message: "maintenance at noon"})
+	require.NoError(t, err)
+	res := alice.expect(t, "the announcement", func(res *chat.StreamResponse) bool { return res.GetServerAnnouncement() != nil })
+	require.Equal(t, "maintenance at noon", res.GetServerAnnouncement().Message)
+	require.Equal(t, "mod", res.GetServerAnnouncement().From)
+
+	// ドレイン中は新しいログインを受け付けず、ヘルスチェックも失敗する
+	drained, err := adm.Drain(modCtx, new(chat.DrainRequest))
+	require.NoError(t, err)
+	require.Equal(t, int32(2), drained.LoggedIn)
+
+	_, err = chat.NewChatClient(ts.conn(t)).Login(ctx, &chat.LoginRequest{Name: "bob", Password: testPassword})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	health, err := healthpb.NewHealthClient(ts.conn(t)).Check(ctx, new(healthpb.HealthCheckRequest))
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, health.Status)
+	stats, err = adm.Stats(modCtx, new(chat.StatsRequest))
+	require.NoError(t, err)
+	require.True(t, stats.Draining)
+
+	_, err = adm.Drain(modCtx, &chat.DrainRequest{Resume: true})
+	require.NoError(t, err)
+	ts.startClient(t, "bob")
+	health, err = healthpb.NewHealthClient(ts.conn(t)).Check(ctx, new(healthpb.HealthCheckRequest))
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+}
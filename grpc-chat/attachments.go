@@ -0,0 +1,479 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// 添付できるファイルのサイズの上限のデフォルト値(バイト)
+	defaultMaxUpload = 10 << 20
+	// ファイルの中身を分割して送るときの1メッセージあたりの大きさ
+	blobChunkSize = 64 << 10
+	// クライアントがアップロードやダウンロードを諦めるまでの時間
+	transferTimeout = 5 * time.Minute
+	// MIMEタイプが分からないときに使う値
+	defaultMimeType = "application/octet-stream"
+)
+
+var (
+	errBlobTooLarge = errors.New("file is too large")
+	errBlobMismatch = errors.New("sha256 does not match the content")
+	errBlobNotFound = errors.New("attachment not found")
+)
+
+// 添付ファイルを中身のsha256をアドレスとして保存するディレクトリ、同じ中身のファイルは1つだけ保存する
+type blobStore struct {
+	dir string
+	// 1つのファイルのサイズの上限(バイト)
+	MaxSize int64
+	// 同じ中身のファイルの情報を同時に更新しないようにする
+	mtx sync.Mutex
+}
+
+// 添付ファイルの情報、中身と並べてJSONファイルに保存する
+type blobMeta struct {
+	Filename string `json:"filename"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	// 添付されたルーム、これらのルームの参加者だけがダウンロードできる
+	Rooms []string `json:"rooms"`
+}
+
+// ファイルの情報を読み込む関数
+func readBlobMeta(path string) (blobMeta, error) {
+	var meta blobMeta
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, errors.WithMessage(err, "unable to parse blob metadata")
+	}
+	return meta, nil
+}
+
+// dirに保存するblobStoreを生成する関数、ディレクトリが無いときは作成する
+func newBlobStore(dir string, maxSize int64) (*blobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.WithMessage(err, "unable to create blob directory")
+	}
+	return &blobStore{dir: dir, MaxSize: maxSize}, nil
+}
+
+// IDのファイルの保存先を返すメソッド、IDの先頭2文字のディレクトリに分けて1つのディレクトリにファイルが溜まりすぎないようにする
+func (b *blobStore) path(id string) string {
+	return filepath.Join(b.dir, id[:2], id)
+}
+
+// sha256の16進表記として正しいIDかを確認する関数、パスに使うので外部から受け取ったIDは必ず確認する
+func validBlobID(id string) bool {
+	_, err := hex.DecodeString(id)
+	return err == nil && len(id) == sha256.Size*2 && strings.ToLower(id) == id
+}
+
+// 受け取った中身を一時ファイルに書き込み、sha256を計算するライター
+type blobWriter struct {
+	store *blobStore
+	tmp   *os.File
+	hash  hash.Hash
+	// これまでに書き込んだバイト数
+	Size int64
+}
+
+// 新しいファイルの書き込みを始めるメソッド、Commitで保存するかAbortで破棄する
+func (b *blobStore) Create() (*blobWriter, error) {
+	tmp, err := os.CreateTemp(b.dir, ".upload-*")
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to create temporary file")
+	}
+	return &blobWriter{store: b, tmp: tmp, hash: sha256.New()}, nil
+}
+
+// 中身を書き込むメソッド、サイズの上限を超えるときはerrBlobTooLargeを返す
+func (w *blobWriter) Write(p []byte) (int, error) {
+	if w.Size+int64(len(p)) > w.store.MaxSize {
+		return 0, errBlobTooLarge
+	}
+	n, err := w.tmp.Write(p)
+	w.hash.Write(p[:n])
+	w.Size += int64(n)
+	return n, err
+}
+
+// 書き込んだ中身のsha256がwantと一致することを確認して保存し、IDを返すメソッド
+func (w *blobWriter) Commit(meta blobMeta, want string) (string, error) {
+	id := hex.EncodeToString(w.hash.Sum(nil))
+	if !strings.EqualFold(want, id) {
+		return "", errBlobMismatch
+	}
+	if err := w.tmp.Close(); err != nil {
+		return "", errors.WithMessage(err, "unable to write blob")
+	}
+
+	dst := w.store.path(id)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", errors.WithMessage(err, "unable to create blob directory")
+	}
+	if err := os.Rename(w.tmp.Name(), dst); err != nil {
+		return "", errors.WithMessage(err, "unable to store blob")
+	}
+
+	// 同じ中身のファイルが既にあるときは最初に添付されたときの情報を残し、添付されたルームだけを加える
+	w.store.mtx.Lock()
+	defer w.store.mtx.Unlock()
+
+	old, err := readBlobMeta(dst + ".json")
+	switch {
+	case err == nil:
+		rooms := old.Rooms
+		for _, room := range meta.Rooms {
+			if !slices.Contains(rooms, room) {
+				rooms = append(rooms, room)
+			}
+		}
+		if len(rooms) == len(old.Rooms) {
+			return id, nil
+		}
+		meta, meta.Rooms = old, rooms
+	case os.IsNotExist(err):
+		meta.Size = w.Size
+	default:
+		return "", errors.WithMessage(err, "unable to read blob metadata")
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", errors.WithMessage(err, "unable to encode blob metadata")
+	}
+	if err := writeFileAtomic(dst+".json", data); err != nil {
+		return "", errors.WithMessage(err, "unable to write blob metadata")
+	}
+	return id, nil
+}
+
+// 書き込みを破棄するメソッド、Commitの後に呼び出しても何もしない
+func (w *blobWriter) Abort() {
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
+
+// IDのファイルとその情報を返すメソッド、無いときはerrBlobNotFoundを返す
+func (b *blobStore) Open(id string) (*os.File, blobMeta, error) {
+	if !validBlobID(id) {
+		return nil, blobMeta{}, errBlobNotFound
+	}
+
+	b.mtx.Lock()
+	meta, err := readBlobMeta(b.path(id) + ".json")
+	b.mtx.Unlock()
+	if os.IsNotExist(err) {
+		return nil, meta, errBlobNotFound
+	} else if err != nil {
+		return nil, meta, errors.WithMessage(err, "unable to read blob metadata")
+	}
+
+	f, err := os.Open(b.path(id))
+	if os.IsNotExist(err) {
+		return nil, meta, errBlobNotFound
+	} else if err != nil {
+		return nil, meta, errors.WithMessage(err, "unable to open blob")
+	}
+	return f, meta, nil
+}
+
+// ファイルを受け取って保存し、ルームに添付を知らせるメソッド
+func (s *server) Upload(srv chat.Chat_UploadServer) error {
+	payload := sessionFrom(srv.Context())
+	tkn, name := payload.ID, payload.Username
+
+	if s.Blobs == nil {
+		return status.Error(codes.FailedPrecondition, "attachments are disabled on this server")
+	}
+
+	req, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+	info := req.GetFile()
+	if info == nil {
+		return status.Error(codes.InvalidArgument, "the first message must describe the file")
+	}
+
+	// クライアントのパスの区切りに関わらずファイル名だけを残す
+	filename := path.Base(strings.ReplaceAll(info.Filename, `\`, "/"))
+	room := normalizeRoom(info.Room)
+	switch {
+	case filename == "." || filename == "/":
+		return status.Error(codes.InvalidArgument, "missing filename")
+	case !validBlobID(strings.ToLower(info.Sha256)):
+		return status.Error(codes.InvalidArgument, "missing or invalid sha256")
+	case info.Size > s.Blobs.MaxSize:
+		return status.Errorf(codes.ResourceExhausted, "file is larger than the %s limit", byteSize(s.Blobs.MaxSize))
+	case !s.inRoom(room, tkn):
+		return status.Errorf(codes.PermissionDenied, "not a member of room %q", room)
+	}
+	if until, ok := s.muted(name); ok {
+		return status.Error(codes.PermissionDenied, "you are muted"+untilText(timestamp(until)))
+	}
+	if err := s.checkUpload(name, info.Size); err != nil {
+		return err
+	}
+	s.touch(tkn)
+
+	w, err := s.Blobs.Create()
+	if err != nil {
+		s.Log.Error("failed to store upload", "token", tkn, "err", err)
+		return status.Error(codes.Internal, "unable to store the file")
+	}
+	defer w.Abort()
+
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if _, err := w.Write(req.GetChunk()); errors.Is(err, errBlobTooLarge) {
+			return status.Errorf(codes.ResourceExhausted, "file is larger than the %s limit", byteSize(s.Blobs.MaxSize))
+		} else if err != nil {
+			s.Log.Error("failed to store upload", "token", tkn, "err", err)
+			return status.Error(codes.Internal, "unable to store the file")
+		}
+	}
+	if w.Size != info.Size {
+		return status.Errorf(codes.InvalidArgument, "received %d bytes, expected %d", w.Size, info.Size)
+	}
+
+	mimeType := info.MimeType
+	if mimeType == "" {
+		mimeType = defaultMimeType
+	}
+	id, err := w.Commit(blobMeta{Filename: filename, MimeType: mimeType, Rooms: []string{room}}, info.Sha256)
+	if errors.Is(err, errBlobMismatch) {
+		return status.Error(codes.DataLoss, "sha256 does not match the received content")
+	} else if err != nil {
+		s.Log.Error("failed to store upload", "token", tkn, "err", err)
+		return status.Error(codes.Internal, "unable to store the file")
+	}
+	s.Log.Info("attached file", "token", tkn, "name", name, "filename", filename, "size", w.Size, "id", id)
+
+	s.Broadcast <- &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_FileAttachment{
+			FileAttachment: &chat.StreamResponse_Attachment{
+				Id:       id,
+				Name:     name,
+				Filename: filename,
+				MimeType: mimeType,
+				Size:     w.Size,
+				Room:     room,
+			},
+		},
+	}
+
+	return srv.SendAndClose(&chat.UploadResponse{Id: id})
+}
+
+// 添付されたファイルの情報と中身を返すメソッド、ファイルが添付されたルームのいずれかの参加者だけがダウンロードできる
+func (s *server) Download(req *chat.DownloadRequest, srv chat.Chat_DownloadServer) error {
+	tkn := sessionFrom(srv.Context()).ID
+
+	if s.Blobs == nil {
+		return status.Error(codes.FailedPrecondition, "attachments are disabled on this server")
+	}
+
+	id := strings.ToLower(req.Id)
+	f, meta, err := s.Blobs.Open(id)
+	if errors.Is(err, errBlobNotFound) {
+		return status.Errorf(codes.NotFound, "attachment %q not found", req.Id)
+	} else if err != nil {
+		s.Log.Error("failed to open attachment", "id", id, "err", err)
+		return status.Error(codes.Internal, "unable to read the file")
+	}
+	defer f.Close()
+	if !slices.ContainsFunc(meta.Rooms, func(room string) bool { return s.inRoom(room, tkn) }) {
+		return status.Errorf(codes.PermissionDenied, "not a member of a room attachment %q was posted in", req.Id)
+	}
+
+	err = srv.Send(&chat.DownloadResponse{Data: &chat.DownloadResponse_File{
+		File: &chat.FileInfo{Filename: meta.Filename, MimeType: meta.MimeType, Size: meta.Size, Sha256: id},
+	}})
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, blobChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := srv.Send(&chat.DownloadResponse{Data: &chat.DownloadResponse_Chunk{Chunk: buf[:n]}}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			s.Log.Error("failed to read attachment", "id", id, "err", err)
+			return status.Error(codes.Internal, "unable to read the file")
+		}
+	}
+}
+
+// ファイルをアップロードしてroomに添付し、IDを返すメソッド
+func (c *client) upload(ctx context.Context, file, room string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if st.IsDir() {
+		return "", errors.Errorf("%s is a directory", file)
+	}
+
+	// サーバが受け取った中身と照合できるよう、送る前にsha256を計算する
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	// 拡張子から分からないときは先頭の中身から推測する
+	mimeType := mime.TypeByExtension(filepath.Ext(file))
+	if mimeType == "" {
+		head := make([]byte, 512)
+		n, _ := f.ReadAt(head, 0)
+		mimeType = http.DetectContentType(head[:n])
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	stream, err := c.ChatClient.Upload(outgoingContext(ctx, c.token(), 0))
+	if err != nil {
+		return "", err
+	}
+	err = stream.Send(&chat.UploadRequest{Data: &chat.UploadRequest_File{File: &chat.FileInfo{
+		Filename: filepath.Base(file),
+		MimeType: mimeType,
+		Size:     st.Size(),
+		Sha256:   hex.EncodeToString(h.Sum(nil)),
+		Room:     room,
+	}}})
+
+	buf := make([]byte, blobChunkSize)
+	for err == nil {
+		var n int
+		n, err = f.Read(buf)
+		if n > 0 {
+			if serr := stream.Send(&chat.UploadRequest{Data: &chat.UploadRequest_Chunk{Chunk: buf[:n]}}); serr != nil {
+				err = serr
+			}
+		}
+	}
+	// サーバが途中でストリームを閉じたときはio.EOFになるので、理由はCloseAndRecvで受け取る
+	if err != io.EOF {
+		return "", err
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return "", err
+	}
+	return res.Id, nil
+}
+
+// 添付されたファイルをダウンロードしてdestに保存し、保存先を返すメソッド、destが既存のディレクトリのときはその中に添付時のファイル名で保存する
+func (c *client) download(ctx context.Context, id, dest string) (string, error) {
+	stream, err := c.ChatClient.Download(outgoingContext(ctx, c.token(), 0), &chat.DownloadRequest{Id: id})
+	if err != nil {
+		return "", err
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		return "", err
+	}
+	info := res.GetFile()
+	if info == nil {
+		return "", errors.New("the server did not describe the file")
+	}
+
+	if st, err := os.Stat(dest); err == nil && st.IsDir() {
+		dest = filepath.Join(dest, filepath.Base(info.Filename))
+	}
+
+	// 途中で失敗したときに不完全なファイルを残さないよう、一時ファイルに書き込んでから置き換える
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	out := io.MultiWriter(tmp, h)
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if _, err := out.Write(res.GetChunk()); err != nil {
+			return "", err
+		}
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, info.Sha256) {
+		return "", errBlobMismatch
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return dest, os.Rename(tmp.Name(), dest)
+}
+
+// 添付のイベントを表示用の文字列にする関数
+func attachmentText(a *chat.StreamResponse_Attachment) string {
+	return fmt.Sprintf("attached %s (%s, %s)  <%s>", a.Filename, a.MimeType, byteSize(a.Size), a.Id)
+}
+
+// バイト数を読みやすい単位の文字列にする関数
+func byteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 1つのチャンクでファイルをアップロードする関数
+func uploadChunk(t *testing.T, ctx context.Context, cc chat.ChatClient, info *chat.FileInfo, chunk string) error {
+	t.Helper()
+
+	stream, err := cc.Upload(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&chat.UploadRequest{Data: &chat.UploadRequest_File{File: info}}))
+	stream.Send(&chat.UploadRequest{Data: &chat.UploadRequest_Chunk{Chunk: []byte(chunk)}})
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// ファイルの情報を受け取るところまでダウンロードする関数
+func downloadInfo(ctx context.Context, cc chat.ChatClient, id string) error {
+	stream, err := cc.Download(ctx, &chat.DownloadRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	_, err = stream.Recv()
+	return err
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestBlobStore(t *testing.T) {
+	blobs, err := newBlobStore(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	// 中身が宣言したsha256と一致しないときは保存しない
+	w, err := blobs.Create()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	_, err = w.Commit(blobMeta{Filename: "a.txt"}, sha256Hex("world"))
+	require.ErrorIs(t, err, errBlobMismatch)
+	w.Abort()
+
+	w, err = blobs.Create()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	id, err := w.Commit(blobMeta{Filename: "a.txt", MimeType: "text/plain"}, sha256Hex("hello"))
+	require.NoError(t, err)
+	require.Equal(t, sha256Hex("hello"), id)
+	w.Abort()
+
+	f, meta, err := blobs.Open(id)
+	require.NoError(t, err)
+	data, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(data))
+	require.Equal(t, blobMeta{Filename: "a.txt", MimeType: "text/plain", Size: 5}, meta)
+
+	// 上限を超える書き込みは拒否する
+	w, err = blobs.Create()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello, world"))
+	require.ErrorIs(t, err, errBlobTooLarge)
+	w.Abort()
+
+	// パスとして使えないIDや存在しないIDは見つからない
+	for _, id := range []string{"../../etc/passwd", strings.ToUpper(id), sha256Hex("missing")} {
+		_, _, err = blobs.Open(id)
+		require.ErrorIs(t, err, errBlobNotFound, id)
+	}
+
+	// 一時ファイルは残らない
+	entries, err := os.ReadDir(blobs.dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestUploadDownload(t *testing.T) {
+	ts := startServer(t, func(s *server) {
+		blobs, err := newBlobStore(t.TempDir(), 1<<20)
+		require.NoError(t, err)
+		s.Blobs = blobs
+	})
+
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "notes.txt")
+	content := strings.Repeat("attachment ", 10000)
+	require.NoError(t, os.WriteFile(src, []byte(content), 0o600))
+
+	alice.say(t, "/send "+src)
+	res := bob.expect(t, "alice's attachment", func(res *chat.StreamResponse) bool { return res.GetFileAttachment() != nil })
+	a := res.GetFileAttachment()
+	require.Equal(t, "alice", a.Name)
+	require.Equal(t, "notes.txt", a.Filename)
+	require.Equal(t, "text/plain; charset=utf-8", a.MimeType)
+	require.Equal(t, int64(len(content)), a.Size)
+	require.Equal(t, sha256Hex(content), a.Id)
+	require.NotZero(t, res.Sequence)
+
+	// ディレクトリを指定したときは添付時のファイル名で保存する
+	out := t.TempDir()
+	bob.say(t, "/get "+a.Id+" "+out)
+	dst := filepath.Join(out, "notes.txt")
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(dst)
+		return err == nil && string(data) == content
+	}, testTimeout, 10*time.Millisecond)
+
+	// 宣言したsha256と中身が一致しないアップロードは拒否される
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	login, err := cc.Login(ctx, &chat.LoginRequest{Name: "carol", Password: testPassword})
+	require.NoError(t, err)
+	ctx = outgoingContext(ctx, login.Token, 0)
+
+	upload := func(info *chat.FileInfo, chunk string) error { return uploadChunk(t, ctx, cc, info, chunk) }
+	err = upload(&chat.FileInfo{Filename: "x.txt", Size: 5, Sha256: sha256Hex("world")}, "hello")
+	require.Equal(t, codes.DataLoss, status.Code(err))
+	err = upload(&chat.FileInfo{Filename: "x.txt", Size: 2 << 20, Sha256: sha256Hex("hello")}, "hello")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	err = upload(&chat.FileInfo{Filename: "x.txt", Size: 5, Sha256: sha256Hex("hello"), Room: "elsewhere"}, "hello")
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err := cc.Download(ctx, &chat.DownloadRequest{Id: sha256Hex("missing")})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestDownloadRequiresMembership(t *testing.T) {
+	ts := startServer(t, func(s *server) {
+		blobs, err := newBlobStore(t.TempDir(), 1<<20)
+		require.NoError(t, err)
+		s.Blobs = blobs
+	})
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	login := func(name string) context.Context {
+		res, err := cc.Login(ctx, &chat.LoginRequest{Name: name, Password: testPassword})
+		require.NoError(t, err)
+		return outgoingContext(ctx, res.Token, 0)
+	}
+	alice, bob := login("alice"), login("bob")
+	_, err := cc.JoinRoom(alice, &chat.JoinRoomRequest{Room: "dev"})
+	require.NoError(t, err)
+
+	// 参加していないルームに添付されたファイルはダウンロードできない
+	info := &chat.FileInfo{Filename: "plan.txt", Size: 5, Sha256: sha256Hex("plans"), Room: "dev"}
+	require.NoError(t, uploadChunk(t, alice, cc, info, "plans"))
+	require.NoError(t, downloadInfo(alice, cc, info.Sha256))
+	require.Equal(t, codes.PermissionDenied, status.Code(downloadInfo(bob, cc, info.Sha256)))
+
+	// 同じ中身が参加しているルームにも添付されればダウンロードできる
+	info.Room = defaultRoom
+	require.NoError(t, uploadChunk(t, alice, cc, info, "plans"))
+	require.NoError(t, downloadInfo(bob, cc, info.Sha256))
+}
+
+func TestUploadQuota(t *testing.T) {
+	ts := startServer(t, func(s *server) {
+		blobs, err := newBlobStore(t.TempDir(), 1<<20)
+		require.NoError(t, err)
+		s.Blobs = blobs
+		s.Limits.setUploadQuota(8)
+	})
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	res, err := cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+	ctx = outgoingContext(ctx, res.Token, 0)
+
+	require.NoError(t, uploadChunk(t, ctx, cc, &chat.FileInfo{Filename: "a.txt", Size: 5, Sha256: sha256Hex("hello")}, "hello"))
+	err = uploadChunk(t, ctx, cc, &chat.FileInfo{Filename: "b.txt", Size: 5, Sha256: sha256Hex("world")}, "world")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, 1.0, testutil.ToFloat64(ts.Prometheus.rateLimits.WithLabelValues(limitUploads)))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	chat "grpc-chat/protos"
+	"grpc-chat/token"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// トークンの有効期間のデフォルト値
+const defaultTokenTTL = time.Hour
+
+// トークンの有効期限が切れたときのエラー、再ログインが必要なことをクライアントが判別できるように無効なトークンとは別のメッセージにする
+var (
+	errTokenExpired = status.Error(codes.Unauthenticated, "token has expired")
+	errTokenInvalid = status.Error(codes.Unauthenticated, "invalid token")
+	errTokenRevoked = status.Error(codes.Unauthenticated, "token has been revoked")
+)
+
+// 有効期限を延長した新しいトークンを発行するメソッド、期限切れのトークンは延長できないので再ログインが必要
+func (s *server) Refresh(ctx context.Context, _ *chat.RefreshRequest) (*chat.RefreshResponse, error) {
+	payload := sessionFrom(ctx)
+
+	tkn, renewed, err := s.Tokens.RenewToken(payload, s.TokenTTL)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to issue token")
+	}
+
+	s.setExpiry(payload.ID, renewed.ExpiredAt)
+
+	s.Log.Debug("refreshed token", "token", payload.ID, "name", payload.Username)
+
+	return &chat.RefreshResponse{
+		Token:     tkn,
+		ExpiresAt: timestamppb.New(renewed.ExpiredAt),
+	}, nil
+}
+
+// トークンの署名と有効期限を検証し、セッションの情報を返すメソッド
+// サーバの再起動などでセッションが失われていても、署名が正しく失効していないトークンであればセッションを復元する
+func (s *server) authenticate(tkn string) (*token.Payload, error) {
+	payload, err := s.Tokens.VerifyToken(tkn)
+	switch {
+	case err == token.ErrExpiredToken:
+		return nil, errTokenExpired
+	case err != nil:
+		return nil, errTokenInvalid
+	case s.isRevoked(payload.ID):
+		return nil, errTokenRevoked
+	}
+	if until, banned := s.Bans.Banned(payload.Username); banned {
+		return nil, status.Error(codes.PermissionDenied, "you are banned"+untilText(timestamp(until)))
+	}
+
+	if _, ok := s.getName(payload.ID); !ok {
+		// ログインと同じく、他のセッションが使っている名前は復元によっても奪えない
+		s.evictExpired(payload.Username)
+		if !s.claimName(payload.ID, payload.Username) {
+			// 同じトークンの別のリクエストが先に復元した場合はそのまま使う
+			if name, ok := s.getName(payload.ID); ok && name == payload.Username {
+				return payload, nil
+			}
+			return nil, status.Error(codes.AlreadyExists, "name is already logged in")
+		}
+		s.setPresence(payload.ID, payload.ExpiredAt)
+		s.joinRoom(payload.ID, defaultRoom)
+		s.resumeSession(payload.ID)
+		s.Log.Info("resumed session", "token", payload.ID, "name", payload.Username)
+	}
+
+	return payload, nil
+}
+
+// ログアウトしたセッションを、そのトークンの有効期限まで失効させておくメソッド
+func (s *server) revoke(payload *token.Payload) {
+	s.revokedMtx.Lock()
+	s.Revoked[payload.ID] = payload.ExpiredAt
+	s.revokedMtx.Unlock()
+}
+
+// セッションが失効済みかを確認するメソッド、有効期限を過ぎた記録はここで削除する
+func (s *server) isRevoked(id string) bool {
+	s.revokedMtx.Lock()
+	defer s.revokedMtx.Unlock()
+
+	now := time.Now()
+	for rid, exp := range s.Revoked {
+		if now.After(exp) {
+			delete(s.Revoked, rid)
+		}
+	}
+	_, ok := s.Revoked[id]
+	return ok
+}
+
+// トークンの署名に使う秘密鍵をランダムに生成する関数、この鍵で発行したトークンはサーバを再起動すると無効になる
+func randomSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
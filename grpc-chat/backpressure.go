@@ -0,0 +1,252 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// クライアントごとのストリームのバッファの大きさ
+const streamBufferSize = 100
+
+// クライアントのストリームが一杯になったときの振る舞い
+type backpressurePolicy string
+
+const (
+	// 新しく届いたメッセージを破棄する
+	dropNewest backpressurePolicy = "drop-newest"
+	// バッファの中の最も古いメッセージを破棄して新しいメッセージを入れる
+	dropOldest backpressurePolicy = "drop-oldest"
+	// 受信が追いつかないクライアントをcodes.ResourceExhaustedで切断する
+	disconnectSlow backpressurePolicy = "disconnect"
+	// 空きができるまで一定時間待ち、それでも一杯なら新しいメッセージを破棄する
+	blockWithTimeout backpressurePolicy = "block"
+)
+
+// 文字列からbackpressurePolicyを得る関数、フラグの値の検証に使う
+func parseBackpressurePolicy(v string) (backpressurePolicy, error) {
+	switch p := backpressurePolicy(v); p {
+	case dropNewest, dropOldest, disconnectSlow, blockWithTimeout:
+		return p, nil
+	}
+	return "", errors.Errorf("unknown backpressure policy %q (want drop-newest, drop-oldest, disconnect or block)", v)
+}
+
+// 送信待ちのイベントと、その直前に破棄したメッセージの件数
+type queuedEvent struct {
+	res     *chat.StreamResponse
+	dropped int64
+}
+
+// クライアント1つ分のストリーム、送信待ちのイベントと破棄した件数を保持する
+type clientStream struct {
+	// 送信待ちのイベント、streamBufferSize件まで溜める
+	queue []queuedEvent
+	// 最後に入れたイベントより後に破棄し、まだ次のイベントに記録していない件数
+	dropped int64
+	mtx     sync.Mutex
+	// イベントが入ったときと、取り出して空きができたときに通知するチャネル
+	ready chan struct{}
+	space chan struct{}
+	// ストリームが解放されたときに閉じられるチャネル
+	done chan struct{}
+	// 切断するときに閉じられるチャネル
+	kicked   chan struct{}
+	kickOnce sync.Once
+	// シャットダウンのときに閉じられるチャネルと、溜まっているメッセージを送り終えた後に送る通知
+	closing   chan struct{}
+	closeOnce sync.Once
+	farewell  *chat.StreamResponse
+}
+
+func newClientStream() *clientStream {
+	return &clientStream{
+		queue:   make([]queuedEvent, 0, streamBufferSize),
+		ready:   make(chan struct{}, 1),
+		space:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		kicked:  make(chan struct{}),
+		closing: make(chan struct{}),
+	}
+}
+
+// 待っている側がいなくても通知を1件まで残しておく関数
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// 空きがあればイベントを入れるメソッド、直前に破棄した件数も合わせて記録する、cs.mtxを取得した状態で呼び出す
+func (cs *clientStream) pushLocked(res *chat.StreamResponse) bool {
+	if len(cs.queue) >= streamBufferSize {
+		return false
+	}
+	cs.queue = append(cs.queue, queuedEvent{res: res, dropped: cs.dropped})
+	cs.dropped = 0
+	notify(cs.ready)
+	return true
+}
+
+// 空きがあればイベントを入れるメソッド
+func (cs *clientStream) push(res *chat.StreamResponse) bool {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+	return cs.pushLocked(res)
+}
+
+// 最も古いイベントを取り出すメソッド、空のときはfalseを返す
+func (cs *clientStream) pop() (queuedEvent, bool) {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	if len(cs.queue) == 0 {
+		return queuedEvent{}, false
+	}
+	e := cs.queue[0]
+	cs.queue[0] = queuedEvent{}
+	cs.queue = cs.queue[1:]
+	if len(cs.queue) > 0 {
+		notify(cs.ready)
+	}
+	notify(cs.space)
+	return e, true
+}
+
+// 送信待ちの最も古いイベントを破棄するメソッド、破棄した件数は次に送るイベントの前に知らせる、cs.mtxを取得した状態で呼び出す
+func (cs *clientStream) dropOldestLocked() {
+	if len(cs.queue) == 0 {
+		return
+	}
+	n := cs.queue[0].dropped + 1
+	cs.queue[0] = queuedEvent{}
+	cs.queue = cs.queue[1:]
+	if len(cs.queue) > 0 {
+		cs.queue[0].dropped += n
+	} else {
+		cs.dropped += n
+	}
+}
+
+// 新しく届いたイベントを破棄したことを記録するメソッド、件数は次に入れるイベントの前に知らせる
+func (cs *clientStream) drop() {
+	cs.mtx.Lock()
+	cs.dropped++
+	cs.mtx.Unlock()
+}
+
+// 最後に入れたイベントより後に破棄した件数を取り出してリセットするメソッド
+func (cs *clientStream) takeDropped() int64 {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+	n := cs.dropped
+	cs.dropped = 0
+	return n
+}
+
+// クライアントを切断するよう送信ループに通知するメソッド
+func (cs *clientStream) kick() {
+	cs.kickOnce.Do(func() { close(cs.kicked) })
+}
+
+// 溜まっているメッセージを送り終えたらresを送ってストリームを終了するよう送信ループに通知するメソッド
+func (cs *clientStream) close(res *chat.StreamResponse) {
+	cs.closeOnce.Do(func() {
+		cs.farewell = res
+		close(cs.closing)
+	})
+}
+
+// 設定された方針に従ってクライアントのストリームにメッセージを入れるメソッド、streamsMtxの読み取りロックを取得した状態で呼び出す
+// blockの方針でストリームが一杯のときはfalseを返し、呼び出し側がロックを解放してからwaitDeliverで空きを待つ
+func (s *server) deliver(tkn string, cs *clientStream, res *chat.StreamResponse) bool {
+	// シャットダウンの通知はストリームが一杯でも破棄せず、溜まっているメッセージの後に送る
+	if res.GetServerShutdown() != nil {
+		cs.close(res)
+		return true
+	}
+
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	if cs.pushLocked(res) {
+		return true
+	}
+	switch s.Backpressure {
+	case dropOldest:
+		cs.dropOldestLocked()
+		cs.pushLocked(res)
+		s.countDrop()
+		return true
+	case disconnectSlow:
+		s.Log.Debug("client stream is full, disconnecting", "token", tkn)
+		cs.kick()
+		return true
+	case blockWithTimeout:
+		return false
+	}
+
+	cs.dropped++
+	s.countDrop()
+	s.Log.Warn("client stream is full, dropping message", "token", tkn)
+	return true
+}
+
+// ストリームに空きができるまでBlockTimeoutの間待ってメッセージを入れるメソッド、間に合わなければ破棄する
+func (s *server) waitDeliver(tkn string, cs *clientStream, res *chat.StreamResponse) {
+	t := time.NewTimer(s.BlockTimeout)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-cs.space:
+			if cs.push(res) {
+				return
+			}
+		case <-cs.done:
+			return
+		case <-t.C:
+			cs.drop()
+			s.countDrop()
+			s.Log.Warn("client stream is full, dropping message", "token", tkn)
+			return
+		}
+	}
+}
+
+// サーバ全体の破棄した件数を数えるメソッド
+func (s *server) countDrop() {
+	s.droppedTotal.Add(1)
+	s.Prometheus.dropped.WithLabelValues(dropSlowClient).Inc()
+}
+
+// 送信待ちだったイベントをクライアントに送信するメソッド、直前に破棄したメッセージがあれば先にその件数を知らせる
+func (s *server) sendQueued(srv chat.Chat_StreamServer, tkn string, e queuedEvent) error {
+	if e.dropped > 0 {
+		if err := s.send(srv, tkn, messagesDropped(e.dropped)); err != nil {
+			return err
+		}
+	}
+	if err := s.send(srv, tkn, e.res); err != nil {
+		return err
+	}
+	s.Prometheus.observeFanOut(e.res)
+	return nil
+}
+
+// 破棄したメッセージの件数をクライアントに知らせるイベントを生成する関数
+func messagesDropped(n int64) *chat.StreamResponse {
+	return &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_MessagesDropped{
+			MessagesDropped: &chat.StreamResponse_Dropped{
+				Count: n,
+			},
+		},
+	}
+}
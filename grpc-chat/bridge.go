@@ -0,0 +1,352 @@
+package main
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// RESTのリクエストの本文の大きさの上限
+	maxBridgeBody = 1 << 20
+	// WebSocketの接続を保つためにpingを送る間隔
+	bridgePingInterval = 30 * time.Second
+	// WebSocketを閉じるときのステータスコードの基準値、4000にgRPCのステータスコードを加えた値で閉じる
+	wsCloseBase = 4000
+)
+
+// ブラウザ向けの最小限のクライアント
+//
+//go:embed static
+var staticFiles embed.FS
+
+// ブラウザなどのHTTPクライアントをgRPCのChatサービスへ中継するブリッジ
+// LoginとLogout、RefreshはJSONのREST、StreamはWebSocketで提供し、メッセージはprotojsonで変換する
+type bridge struct {
+	chat.ChatClient
+	// HTTPで待ち受けるアドレスと、中継先のgRPCサーバのアドレス
+	Addr, Host string
+	// 待ち受けるリスナー、nilのときはAddrでTCPのポートを開く
+	Listener net.Listener
+	// gRPCサーバとのTLSの認証情報、nilのときは平文で通信する
+	Creds credentials.TransportCredentials
+	// 接続時に追加するオプション、インメモリの接続を使うときなどに指定する
+	DialOptions []grpc.DialOption
+	// 診断用のログの出力先
+	Log *slog.Logger
+
+	upgrader websocket.Upgrader
+}
+
+// 構造体bridgeを生成する関数
+func Bridge(addr, host string) *bridge {
+	return &bridge{Addr: addr, Host: host, Log: logger.With("component", "bridge")}
+}
+
+// gRPCサーバに接続してHTTPの待ち受けを開始するメソッド、ctxが終了するまで中継を続ける
+func (b *bridge) Run(ctx context.Context) error {
+	connCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	creds := b.Creds
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds), grpc.WithBlock()}, b.DialOptions...)
+	conn, err := grpc.DialContext(connCtx, b.Host, opts...)
+	if err != nil {
+		return errors.WithMessage(err, "failed to connect to server")
+	}
+	defer conn.Close()
+	b.ChatClient = chat.NewChatClient(conn)
+
+	lis := b.Listener
+	if lis == nil {
+		if lis, err = net.Listen("tcp", b.Addr); err != nil {
+			return errors.WithMessage(err, "failed to open listener")
+		}
+	}
+
+	// リクエストのコンテキストをctxから派生させ、ctxが終了したら中継しているストリームも閉じる
+	srv := &http.Server{
+		Handler:           b.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		b.Log.Info("shutting down")
+		// WebSocketの接続はShutdownでは閉じられないが、BaseContextによりctxの終了で閉じられる
+		sctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(sctx)
+	}()
+
+	b.Log.Info("listening", "addr", lis.Addr().String(), "host", b.Host)
+	if err := srv.Serve(lis); err != http.ErrServerClosed {
+		return errors.WithMessage(err, "http bridge stopped")
+	}
+	return nil
+}
+
+// ブリッジのエンドポイントを登録したハンドラを返すメソッド
+func (b *bridge) handler() http.Handler {
+	static, _ := fs.Sub(staticFiles, "static")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/login", b.login)
+	mux.HandleFunc("/v1/logout", b.logout)
+	mux.HandleFunc("/v1/refresh", b.refresh)
+	mux.HandleFunc("/v1/stream", b.stream)
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	return mux
+}
+
+// POST /v1/login、本文のLoginRequestでログインしてLoginResponseを返す
+// サーバがブラウザごとにLoginの頻度を制限できるよう、ブラウザのIPアドレスを送る
+func (b *bridge) login(w http.ResponseWriter, r *http.Request) {
+	req := new(chat.LoginRequest)
+	if !readProto(w, r, req) {
+		return
+	}
+	ctx := r.Context()
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ctx = withClientIP(ctx, ip)
+	}
+	res, err := b.ChatClient.Login(ctx, req)
+	writeProto(w, res, err)
+}
+
+// POST /v1/logout、本文かAuthorizationヘッダのトークンのセッションをログアウトする
+func (b *bridge) logout(w http.ResponseWriter, r *http.Request) {
+	req := new(chat.LogoutRequest)
+	if !readProto(w, r, req) {
+		return
+	}
+	if req.Token == "" {
+		req.Token = bearerToken(r)
+	}
+	res, err := b.ChatClient.Logout(outgoingContext(r.Context(), req.Token, 0), req)
+	writeProto(w, res, err)
+}
+
+// POST /v1/refresh、本文かAuthorizationヘッダのトークンを新しいトークンに交換する
+func (b *bridge) refresh(w http.ResponseWriter, r *http.Request) {
+	req := new(chat.RefreshRequest)
+	if !readProto(w, r, req) {
+		return
+	}
+	if req.Token == "" {
+		req.Token = bearerToken(r)
+	}
+	res, err := b.ChatClient.Refresh(outgoingContext(r.Context(), req.Token, 0), req)
+	writeProto(w, res, err)
+}
+
+// GET /v1/stream?token=...&last_seq=...、WebSocketに切り替えてStreamを中継する
+// ブラウザのWebSocketはヘッダを指定できないので、トークンはクエリでも受け付ける
+// 受け取ったテキストフレームはStreamRequest、送るテキストフレームはStreamResponseのprotojson
+func (b *bridge) stream(w http.ResponseWriter, r *http.Request) {
+	tkn := bearerToken(r)
+	if tkn == "" {
+		tkn = r.URL.Query().Get("token")
+	}
+	lastSeq, _ := strconv.ParseUint(r.URL.Query().Get("last_seq"), 10, 64)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	stream, err := b.ChatClient.Stream(outgoingContext(ctx, tkn, lastSeq))
+	if err != nil {
+		writeProto(w, nil, err)
+		return
+	}
+	// 認証に失敗したときはヘッダが届かないので、切り替える前にHTTPのステータスで返す
+	if md, _ := stream.Header(); md == nil {
+		if _, err = stream.Recv(); err == nil || err == io.EOF {
+			err = status.Error(codes.Unavailable, "stream closed")
+		}
+		writeProto(w, nil, err)
+		return
+	}
+
+	conn, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgradeがエラーのレスポンスを返している
+		return
+	}
+	defer conn.Close()
+
+	var writeMtx sync.Mutex
+	write := func(res *chat.StreamResponse) error {
+		data, err := protojson.Marshal(res)
+		if err != nil {
+			return err
+		}
+		writeMtx.Lock()
+		defer writeMtx.Unlock()
+		return conn.WriteMessage(websocket.TextMessage, data)
+	}
+
+	// ブラウザからのフレームをサーバへ送り、ブラウザが閉じたときはストリームも閉じる
+	go func() {
+		defer cancel()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := new(chat.StreamRequest)
+			if err := protojson.Unmarshal(data, req); err != nil {
+				write(&chat.StreamResponse{
+					Timestamp: timestamppb.Now(),
+					Event: &chat.StreamResponse_ServerError{
+						ServerError: &chat.StreamResponse_Error{Message: "invalid frame: " + err.Error()},
+					},
+				})
+				continue
+			}
+			if err := stream.Send(req); err != nil {
+				return
+			}
+		}
+	}()
+
+	// 途中のプロキシに切断されないよう定期的にpingを送る
+	go func() {
+		ticker := time.NewTicker(bridgePingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			closeCode, reason := websocket.CloseNormalClosure, ""
+			if s, ok := status.FromError(err); ok && err != io.EOF && s.Code() != codes.Canceled {
+				closeCode, reason = wsCloseBase+int(s.Code()), s.Message()
+			}
+			writeMtx.Lock()
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, reason), time.Now().Add(time.Second))
+			writeMtx.Unlock()
+			return
+		}
+		if err := write(res); err != nil {
+			return
+		}
+	}
+}
+
+// 本文のJSONをmsgに読み込む関数、POSTでないときや読み込めないときはエラーを返してfalseを返す
+func readProto(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, status.New(codes.Unimplemented, "method not allowed").Proto())
+		return false
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBridgeBody))
+	if err != nil {
+		writeProto(w, nil, status.Error(codes.InvalidArgument, "unable to read request body"))
+		return false
+	}
+	if len(data) == 0 {
+		return true
+	}
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		writeProto(w, nil, status.Error(codes.InvalidArgument, "invalid request body: "+err.Error()))
+		return false
+	}
+	return true
+}
+
+// msgをJSONで返す関数、errがあるときはgoogle.rpc.Statusの形式でエラーを返す
+func writeProto(w http.ResponseWriter, msg proto.Message, err error) {
+	if err != nil {
+		s := status.Convert(err)
+		writeJSON(w, httpStatus(s.Code()), s.Proto())
+		return
+	}
+	writeJSON(w, http.StatusOK, msg)
+}
+
+// msgをステータスコードcodeのJSONで返す関数
+func writeJSON(w http.ResponseWriter, code int, msg proto.Message) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		http.Error(w, "unable to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// Authorization: Bearerヘッダのトークンを返す関数
+func bearerToken(r *http.Request) string {
+	tkn, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(tkn)
+}
+
+// gRPCのステータスコードに対応するHTTPのステータスコードを返す関数
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
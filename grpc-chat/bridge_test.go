@@ -0,0 +1,146 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// bufconnのサーバへ中継するブリッジをループバックのポートで起動する関数、ベースURLを返す
+func startBridge(t *testing.T, ts *testServer) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := Bridge("", "bufnet")
+	b.Listener = lis
+	b.DialOptions = []grpc.DialOption{ts.dialer()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(testTimeout):
+			t.Fatal("bridge did not shut down")
+		}
+	})
+
+	return "http://" + lis.Addr().String()
+}
+
+// JSONの本文をPOSTしてステータスコードと本文を返す関数
+func postJSON(t *testing.T, url, body string, res proto.Message) int {
+	t.Helper()
+
+	r, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	require.NoError(t, protojson.Unmarshal(data, res), string(data))
+	return r.StatusCode
+}
+
+// WebSocketから次のStreamResponseを読む関数
+func readFrame(t *testing.T, ws *websocket.Conn) *chat.StreamResponse {
+	t.Helper()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(testTimeout)))
+	_, data, err := ws.ReadMessage()
+	require.NoError(t, err)
+	res := new(chat.StreamResponse)
+	require.NoError(t, protojson.Unmarshal(data, res), string(data))
+	return res
+}
+
+func TestBridge(t *testing.T) {
+	ts := startServer(t)
+	base := startBridge(t, ts)
+	bob := ts.startClient(t, "bob")
+
+	// 静的なクライアントを配信する
+	r, err := http.Get(base + "/")
+	require.NoError(t, err)
+	page, _ := io.ReadAll(r.Body)
+	r.Body.Close()
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	require.Contains(t, string(page), "/v1/stream")
+
+	// ログインの失敗はgoogle.rpc.Statusで返す
+	st := new(status.Status)
+	require.Equal(t, http.StatusUnauthorized, postJSON(t, base+"/v1/login", `{"name":"web","password":"wrong"}`, st))
+	require.Equal(t, int32(codes.Unauthenticated), st.Code)
+
+	login := new(chat.LoginResponse)
+	require.Equal(t, http.StatusOK, postJSON(t, base+"/v1/login", `{"name":"web","password":"`+testPassword+`"}`, login))
+	require.NotEmpty(t, login.Token)
+	bob.expect(t, "web's login", loginOf("web"))
+
+	// 無効なトークンのストリームはWebSocketに切り替えずに拒否する
+	wsURL := "ws" + strings.TrimPrefix(base, "http") + "/v1/stream"
+	_, res, err := websocket.DefaultDialer.Dial(wsURL+"?token=invalid", nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	ws, _, err := websocket.DefaultDialer.Dial(wsURL+"?token="+login.Token, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+	require.Eventually(t, func() bool { return ts.isOnline("web") }, testTimeout, 10*time.Millisecond)
+
+	// ブラウザからのフレームはStreamRequestとして送られる
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{"message":"hello from the web","room":"lobby"}`)))
+	bob.expect(t, "web's message", messageFrom("web", "hello from the web"))
+
+	// 他のクライアントのメッセージはStreamResponseのフレームとして届く
+	bob.say(t, "hi browser")
+	for {
+		if m := readFrame(t, ws).GetClientMessage(); m.GetName() == "bob" {
+			require.Equal(t, "hi browser", m.Message)
+			break
+		}
+	}
+
+	// 解釈できないフレームにはエラーのイベントを返す
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`not json`)))
+	for {
+		if e := readFrame(t, ws).GetServerError(); e != nil {
+			require.Contains(t, e.Message, "invalid frame")
+			break
+		}
+	}
+
+	// Authorizationヘッダのトークンでログアウトする
+	req, err := http.NewRequest(http.MethodPost, base+"/v1/logout", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+login.Token)
+	r, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	r.Body.Close()
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	bob.expect(t, "web's logout", logoutOf("web"))
+
+	r, err = http.Get(base + "/v1/login")
+	require.NoError(t, err)
+	r.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, r.StatusCode)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Redisでイベントを流すチャネル名と、シーケンス番号を保持するキーのデフォルト値
+	defaultBrokerChannel = "grpc-chat"
+	// 購読したイベントを配信ループに渡すまで溜めておける件数
+	brokerBufferSize = 1000
+	// 1件のイベントの送信を諦めるまでの時間
+	publishTimeout = time.Second
+)
+
+// ブロードキャストされたイベントを全てのサーバのレプリカへ中継するインターフェース
+// ログイン中のユーザ名やルームの参加者、プレゼンスはレプリカごとに保持し、共有するのはイベントと公開鍵だけ
+type Broker interface {
+	// Publish はイベントを購読している全てのレプリカへ送る
+	Publish(ctx context.Context, res *chat.StreamResponse) error
+	// Subscribe は全てのレプリカから届くイベントを受け取るチャネルを返す、ctxが終了するとチャネルは閉じられる
+	Subscribe(ctx context.Context) (<-chan *chat.StreamResponse, error)
+	// NextSequence はafterより大きく、全てのレプリカで重複しない次のシーケンス番号を返す
+	NextSequence(ctx context.Context, after uint64) (uint64, error)
+	// SetPublicKey はユーザが公開した公開鍵を全てのレプリカから引けるよう登録する
+	SetPublicKey(ctx context.Context, name string, key []byte) error
+	// DeletePublicKey は登録されている公開鍵がkeyと同じときだけ削除する、他のセッションが後から登録した鍵は残す
+	DeletePublicKey(ctx context.Context, name string, key []byte) error
+	// PublicKey はユーザの公開鍵を返す、登録されていないときはfalseを返す
+	PublicKey(ctx context.Context, name string) ([]byte, bool, error)
+	// Close は接続などのリソースを解放する
+	Close() error
+}
+
+// "-broker"の値からブローカーを生成する関数、空のときはプロセス内で完結する
+func parseBroker(url string) (Broker, error) {
+	switch {
+	case url == "" || url == "local":
+		return newLocalBroker(), nil
+	case strings.HasPrefix(url, "redis://"), strings.HasPrefix(url, "rediss://"):
+		return newRedisBroker(url, defaultBrokerChannel)
+	default:
+		return nil, errors.Errorf("unknown broker %q (expected local or redis://host:port)", url)
+	}
+}
+
+// 同じプロセス内のサーバだけでイベントを共有するブローカー
+type localBroker struct {
+	seq  atomic.Uint64
+	subs map[chan *chat.StreamResponse]struct{}
+	keys map[string][]byte
+	mtx  sync.RWMutex
+}
+
+func newLocalBroker() *localBroker {
+	return &localBroker{subs: make(map[chan *chat.StreamResponse]struct{}), keys: make(map[string][]byte)}
+}
+
+func (b *localBroker) Publish(ctx context.Context, res *chat.StreamResponse) error {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	for sub := range b.subs {
+		select {
+		case sub <- res:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *localBroker) Subscribe(ctx context.Context) (<-chan *chat.StreamResponse, error) {
+	sub := make(chan *chat.StreamResponse, brokerBufferSize)
+
+	b.mtx.Lock()
+	b.subs[sub] = struct{}{}
+	b.mtx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mtx.Lock()
+		delete(b.subs, sub)
+		close(sub)
+		b.mtx.Unlock()
+	}()
+
+	return sub, nil
+}
+
+func (b *localBroker) NextSequence(_ context.Context, after uint64) (uint64, error) {
+	for {
+		cur := b.seq.Load()
+		next := cur + 1
+		if next <= after {
+			next = after + 1
+		}
+		if b.seq.CompareAndSwap(cur, next) {
+			return next, nil
+		}
+	}
+}
+
+func (b *localBroker) SetPublicKey(_ context.Context, name string, key []byte) error {
+	b.mtx.Lock()
+	b.keys[name] = key
+	b.mtx.Unlock()
+	return nil
+}
+
+func (b *localBroker) DeletePublicKey(_ context.Context, name string, key []byte) error {
+	b.mtx.Lock()
+	if bytes.Equal(b.keys[name], key) {
+		delete(b.keys, name)
+	}
+	b.mtx.Unlock()
+	return nil
+}
+
+func (b *localBroker) PublicKey(_ context.Context, name string) ([]byte, bool, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	key, ok := b.keys[name]
+	return key, ok, nil
+}
+
+func (b *localBroker) Close() error { return nil }
+
+// Redisのpub/subで複数のレプリカの間でイベントを共有するブローカー
+type redisBroker struct {
+	rdb     *redis.Client
+	channel string
+	seqKey  string
+	keysKey string
+}
+
+// シーケンス番号を1つ進める、afterより小さいときはafterの次まで進める
+var nextSequenceScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+local after = tonumber(ARGV[1])
+if n <= after then
+	n = after + 1
+	redis.call('SET', KEYS[1], n)
+end
+return n
+`)
+
+// 登録されている公開鍵が引数と同じときだけ削除する
+var deletePublicKeyScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// URLで指定したRedisに接続してredisBrokerを生成する関数
+func newRedisBroker(url, channel string) (*redisBroker, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid redis URL")
+	}
+	rdb := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		rdb.Close()
+		return nil, errors.WithMessage(err, "unable to connect to redis")
+	}
+
+	return &redisBroker{rdb: rdb, channel: channel, seqKey: channel + ":seq", keysKey: channel + ":keys"}, nil
+}
+
+func (b *redisBroker) Publish(ctx context.Context, res *chat.StreamResponse) error {
+	msg, err := proto.Marshal(res)
+	if err != nil {
+		return errors.WithMessage(err, "unable to encode event")
+	}
+	return errors.WithMessage(b.rdb.Publish(ctx, b.channel, msg).Err(), "unable to publish event")
+}
+
+func (b *redisBroker) Subscribe(ctx context.Context) (<-chan *chat.StreamResponse, error) {
+	ps := b.rdb.Subscribe(ctx, b.channel)
+	// 購読が確立するまで待ち、それ以降に送られたイベントを取りこぼさないようにする
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return nil, errors.WithMessage(err, "unable to subscribe")
+	}
+
+	out := make(chan *chat.StreamResponse, brokerBufferSize)
+	go func() {
+		defer close(out)
+		defer ps.Close()
+
+		msgs := ps.Channel(redis.WithChannelSize(brokerBufferSize))
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				res := new(chat.StreamResponse)
+				if err := proto.Unmarshal([]byte(msg.Payload), res); err != nil {
+					logger.Warn("skipping malformed event", "component", "broker", "err", err)
+					continue
+				}
+				out <- res
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (b *redisBroker) NextSequence(ctx context.Context, after uint64) (uint64, error) {
+	n, err := nextSequenceScript.Run(ctx, b.rdb, []string{b.seqKey}, after).Uint64()
+	if err != nil {
+		return 0, errors.WithMessage(err, "unable to assign sequence number")
+	}
+	return n, nil
+}
+
+func (b *redisBroker) SetPublicKey(ctx context.Context, name string, key []byte) error {
+	return errors.WithMessage(b.rdb.HSet(ctx, b.keysKey, name, key).Err(), "unable to publish public key")
+}
+
+func (b *redisBroker) DeletePublicKey(ctx context.Context, name string, key []byte) error {
+	err := deletePublicKeyScript.Run(ctx, b.rdb, []string{b.keysKey}, name, key).Err()
+	return errors.WithMessage(err, "unable to delete public key")
+}
+
+func (b *redisBroker) PublicKey(ctx context.Context, name string) ([]byte, bool, error) {
+	key, err := b.rdb.HGet(ctx, b.keysKey, name).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, errors.WithMessage(err, "unable to look up public key")
+	}
+	return key, true, nil
+}
+
+func (b *redisBroker) Close() error { return b.rdb.Close() }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// テスト用のRedisを起動し、そこに接続するブローカーを返す関数
+func startRedisBroker(t *testing.T, mr *miniredis.Miniredis) *redisBroker {
+	t.Helper()
+
+	b, err := newRedisBroker("redis://"+mr.Addr(), defaultBrokerChannel)
+	require.NoError(t, err)
+	return b
+}
+
+func testBroker(t *testing.T, b Broker) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	events, err := b.Subscribe(ctx)
+	require.NoError(t, err)
+
+	sent := &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Sequence:  1,
+		Event: &chat.StreamResponse_ClientMessage{
+			ClientMessage: &chat.StreamResponse_Message{Name: "alice", Message: "hello", Room: defaultRoom},
+		},
+	}
+	require.NoError(t, b.Publish(ctx, sent))
+
+	select {
+	case got := <-events:
+		require.True(t, proto.Equal(sent, got), "got %v", got)
+	case <-ctx.Done():
+		t.Fatal("published event was not received")
+	}
+
+	// シーケンス番号は常に増加し、afterより小さくならない
+	seq, err := b.NextSequence(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), seq)
+	seq, err = b.NextSequence(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), seq)
+	seq, err = b.NextSequence(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), seq)
+
+	// 公開鍵は登録した鍵と同じときだけ削除する
+	require.NoError(t, b.SetPublicKey(ctx, "alice", []byte("old")))
+	require.NoError(t, b.SetPublicKey(ctx, "alice", []byte("new")))
+	require.NoError(t, b.DeletePublicKey(ctx, "alice", []byte("old")))
+	key, ok, err := b.PublicKey(ctx, "alice")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("new"), key)
+	require.NoError(t, b.DeletePublicKey(ctx, "alice", []byte("new")))
+	_, ok, err = b.PublicKey(ctx, "alice")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// 購読を解除するとチャネルが閉じられる
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-events
+		return !ok
+	}, testTimeout, 10*time.Millisecond)
+
+	require.NoError(t, b.Close())
+}
+
+func TestLocalBroker(t *testing.T) {
+	testBroker(t, newLocalBroker())
+}
+
+func TestRedisBroker(t *testing.T) {
+	testBroker(t, startRedisBroker(t, miniredis.RunT(t)))
+}
+
+func TestParseBroker(t *testing.T) {
+	b, err := parseBroker("")
+	require.NoError(t, err)
+	require.IsType(t, &localBroker{}, b)
+
+	_, err = parseBroker("nats://localhost:4222")
+	require.Error(t, err)
+}
+
+func TestReplicasShareBroadcasts(t *testing.T) {
+	mr := miniredis.RunT(t)
+	withRedis := func(s *server) { s.Broker = startRedisBroker(t, mr) }
+
+	a := startServer(t, withRedis)
+	b := startServer(t, withRedis)
+
+	alice := a.startClient(t, "alice")
+	bob := b.startClient(t, "bob")
+	alice.expect(t, "bob's login from the other replica", loginOf("bob"))
+
+	bob.say(t, "hello")
+	got := alice.expect(t, "bob's message", messageFrom("bob", "hello"))
+	own := bob.expect(t, "bob's message", messageFrom("bob", "hello"))
+	require.Equal(t, own.Sequence, got.Sequence)
+
+	// 宛先が他のレプリカにいてもダイレクトメッセージは届く
+	alice.say(t, "/msg bob psst")
+	bob.expect(t, "alice's direct message", func(res *chat.StreamResponse) bool {
+		dm := res.GetDirectMessage()
+		return dm.GetFrom() == "alice" && dm.GetMessage() == "psst"
+	})
+
+	// 公開鍵もレプリカの間で共有するので、他のレプリカのユーザにも暗号化したメッセージを送れる
+	alice.say(t, "/secret bob meet at noon")
+	res := bob.expect(t, "alice's encrypted message", func(res *chat.StreamResponse) bool {
+		return res.GetDirectMessage().GetSealed() != nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	text, err := bob.openDirect(ctx, res.GetDirectMessage())
+	require.NoError(t, err)
+	require.Equal(t, "meet at noon", text)
+
+	// シャットダウンは停止したレプリカのクライアントにだけ知らせる
+	b.stop(t)
+	bob.expect(t, "the shutdown", isShutdown)
+	alice.say(t, "still here")
+	alice.expect(t, "alice's message", messageFrom("alice", "still here"))
+	require.False(t, alice.Shutdown)
+}
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	testPassword = "secret"
+	testTimeout  = 5 * time.Second
+)
+
+// インメモリの接続でRunを実行しているサーバ
+type testServer struct {
+	*server
+	lis    *bufconn.Listener
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// bufconnのリスナーでサーバを起動する関数、起動前にoptsでサーバの設定を変更できる、テストの終了時に停止する
+func startServer(t *testing.T, opts ...func(*server)) *testServer {
+	t.Helper()
+
+	ts := &testServer{
+		server: Server("bufnet", testPassword),
+		lis:    bufconn.Listen(1024 * 1024),
+		done:   make(chan error, 1),
+	}
+	ts.Listener = ts.lis
+	for _, opt := range opts {
+		opt(ts.server)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ts.cancel = cancel
+	go func() { ts.done <- ts.Run(ctx) }()
+	t.Cleanup(func() { ts.stop(t) })
+
+	return ts
+}
+
+// サーバを停止してRunの終了を待つメソッド、既に停止しているときは何もしない
+func (ts *testServer) stop(t *testing.T) {
+	t.Helper()
+
+	if ts.cancel == nil {
+		return
+	}
+	ts.cancel()
+	ts.cancel = nil
+
+	select {
+	case err := <-ts.done:
+		require.NoError(t, err)
+	case <-time.After(testTimeout):
+		t.Fatal("server did not shut down")
+	}
+}
+
+// bufconnのリスナーに接続するためのオプション
+func (ts *testServer) dialer() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return ts.lis.DialContext(ctx)
+	})
+}
+
+// Runを実行しているクライアントと、受け取ったイベント
+type testClient struct {
+	*client
+	events chan *chat.StreamResponse
+	input  *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// ログインしてストリームを開いたクライアントを返すメソッド
+func (ts *testServer) startClient(t *testing.T, name string) *testClient {
+	t.Helper()
+
+	tc := runClient(t, name, ts.dialer())
+	require.Eventually(t, func() bool { return ts.isOnline(name) }, testTimeout, 10*time.Millisecond,
+		"%s did not open a stream", name)
+	return tc
+}
+
+// dialerで接続するクライアントのRunを開始する関数、テストの終了時に停止する
+func runClient(t *testing.T, name string, dialer grpc.DialOption) *testClient {
+	t.Helper()
+
+	pr, pw := io.Pipe()
+	tc := &testClient{
+		client: Client("bufnet", testPassword, name),
+		events: make(chan *chat.StreamResponse, 100),
+		input:  pw,
+		done:   make(chan error, 1),
+	}
+	tc.Input = pr
+	tc.OnEvent = func(res *chat.StreamResponse) { tc.events <- res }
+	tc.DialOptions = []grpc.DialOption{dialer}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tc.cancel = cancel
+	go func() { tc.done <- tc.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		pw.Close()
+	})
+	return tc
+}
+
+// 1行を入力してサーバへ送信させるメソッド
+func (tc *testClient) say(t *testing.T, line string) {
+	t.Helper()
+	_, err := fmt.Fprintln(tc.input, line)
+	require.NoError(t, err)
+}
+
+// matchに一致するイベントを受け取るまで待つメソッド、一致しないイベントは読み飛ばす
+func (tc *testClient) expect(t *testing.T, what string, match func(*chat.StreamResponse) bool) *chat.StreamResponse {
+	t.Helper()
+
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case res := <-tc.events:
+			if match(res) {
+				return res
+			}
+		case <-timeout:
+			t.Fatalf("%s did not receive %s", tc.Name, what)
+			return nil
+		}
+	}
+}
+
+// Runの終了を待ち、その戻り値を返すメソッド
+func (tc *testClient) wait(t *testing.T) error {
+	t.Helper()
+
+	select {
+	case err := <-tc.done:
+		return err
+	case <-time.After(testTimeout):
+		t.Fatalf("%s did not stop", tc.Name)
+		return nil
+	}
+}
+
+func loginOf(name string) func(*chat.StreamResponse) bool {
+	return func(res *chat.StreamResponse) bool { return res.GetClientLogin().GetName() == name }
+}
+
+func logoutOf(name string) func(*chat.StreamResponse) bool {
+	return func(res *chat.StreamResponse) bool { return res.GetClientLogout().GetName() == name }
+}
+
+func messageFrom(name, msg string) func(*chat.StreamResponse) bool {
+	return func(res *chat.StreamResponse) bool {
+		m := res.GetClientMessage()
+		return m.GetName() == name && m.GetMessage() == msg
+	}
+}
+
+func isShutdown(res *chat.StreamResponse) bool { return res.GetServerShutdown() != nil }
+
+func TestLoginMessageLogoutBroadcasts(t *testing.T) {
+	ts := startServer(t)
+
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+	alice.expect(t, "bob's login", loginOf("bob"))
+
+	carol := ts.startClient(t, "carol")
+	alice.expect(t, "carol's login", loginOf("carol"))
+	bob.expect(t, "carol's login", loginOf("carol"))
+
+	bob.say(t, "hello")
+	for _, tc := range []*testClient{alice, bob, carol} {
+		res := tc.expect(t, "bob's message", messageFrom("bob", "hello"))
+		require.Equal(t, defaultRoom, res.GetClientMessage().Room)
+		require.NotZero(t, res.Sequence)
+	}
+
+	bob.cancel()
+	require.NoError(t, bob.wait(t))
+	alice.expect(t, "bob's logout", logoutOf("bob"))
+	carol.expect(t, "bob's logout", logoutOf("bob"))
+	require.False(t, ts.isOnline("bob"))
+}
+
+func TestShutdownBroadcast(t *testing.T) {
+	ts := startServer(t)
+
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+
+	ts.stop(t)
+
+	// 通知を受け取ったクライアントは終了せず、再起動を待って再接続を試み続ける
+	for _, tc := range []*testClient{alice, bob} {
+		tc.expect(t, "the shutdown", isShutdown)
+		select {
+		case err := <-tc.done:
+			t.Fatalf("%s stopped after the shutdown: %v", tc.Name, err)
+		case <-time.After(100 * time.Millisecond):
+		}
+		tc.cancel()
+		require.NoError(t, tc.wait(t))
+		require.True(t, tc.Shutdown)
+	}
+}
+
+func TestDuplicateLogin(t *testing.T) {
+	ts := startServer(t)
+	ts.startClient(t, "alice")
+
+	conn := ts.conn(t)
+	_, err := chat.NewChatClient(conn).Login(context.Background(), &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = chat.NewChatClient(conn).Login(context.Background(), &chat.LoginRequest{Name: "bob", Password: "wrong"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestResumeCannotTakeName(t *testing.T) {
+	ts := startServer(t)
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	old, err := cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+
+	// 再起動などでセッションが失われた後に、別のセッションが同じ名前でログインする
+	payload, err := ts.Tokens.VerifyToken(old.Token)
+	require.NoError(t, err)
+	ts.delName(payload.ID)
+	_, err = cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+
+	// 古いトークンでは名前を取り戻せない
+	_, err = cc.ListUsers(outgoingContext(ctx, old.Token, 0), &chat.ListUsersRequest{})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, ok := ts.getName(payload.ID)
+	require.False(t, ok)
+}
+
+func TestStreamTokenMetadata(t *testing.T) {
+	ts := startServer(t)
+	cc := chat.NewChatClient(ts.conn(t))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// トークンの無いストリームは拒否される
+	stream, err := cc.Stream(ctx)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 共通のヘッダで送ったトークンは受け付けられる
+	res, err := cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+
+	stream, err = cc.Stream(outgoingContext(ctx, res.Token, 0))
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+	require.True(t, ts.isOnline("alice"))
+}
+
+// Clientを使わずにサーバへ直接接続するメソッド
+func (ts *testServer) conn(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	conn, err := grpc.Dial("bufnet", ts.dialer(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestSecondStreamReplacesFirst(t *testing.T) {
+	ts := startServer(t)
+	bob := ts.startClient(t, "bob")
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	res, err := cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+	open := func(ctx context.Context) chat.Chat_StreamClient {
+		stream, err := cc.Stream(outgoingContext(ctx, res.Token, 0))
+		require.NoError(t, err)
+		_, err = stream.Header()
+		require.NoError(t, err)
+		return stream
+	}
+
+	firstCtx, closeFirst := context.WithCancel(ctx)
+	first := open(firstCtx)
+	second := open(ctx)
+
+	// 古いストリームは新しいストリームに置き換えられたことを知らされて終了する
+	for {
+		evt, err := first.Recv()
+		require.NoError(t, err)
+		if evt.GetClientKicked() != nil {
+			require.Equal(t, "alice", evt.GetClientKicked().Name)
+			break
+		}
+	}
+	_, err = first.Recv()
+	require.Equal(t, io.EOF, err)
+	closeFirst()
+
+	// 古いストリームが閉じても、新しいストリームには配信が続く
+	bob.say(t, "still there?")
+	for {
+		evt, err := second.Recv()
+		require.NoError(t, err)
+		if messageFrom("bob", "still there?")(evt) {
+			break
+		}
+	}
+	ts.streamsMtx.RLock()
+	n := len(ts.ClientStreams)
+	ts.streamsMtx.RUnlock()
+	require.Equal(t, 2, n)
+	require.True(t, ts.isOnline("alice"))
+}
+
+func TestHistoryRequiresMembership(t *testing.T) {
+	ts := startServer(t)
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+	alice.say(t, "/join dev")
+	alice.say(t, "secret plans")
+	alice.say(t, "/join lobby")
+	alice.say(t, "hello everyone")
+	bob.expect(t, "alice's lobby message", messageFrom("alice", "hello everyone"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := chat.NewChatClient(ts.conn(t))
+	texts := func(res *chat.HistoryResponse) (out []string) {
+		for _, msg := range res.Messages {
+			if m := msg.GetClientMessage(); m != nil {
+				out = append(out, m.Message)
+			}
+		}
+		return out
+	}
+
+	// ルームを指定しないときは参加しているルームの発言だけを返す
+	res, err := c.History(outgoingContext(ctx, alice.token(), 0), &chat.HistoryRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret plans", "hello everyone"}, texts(res))
+	res, err = c.History(outgoingContext(ctx, bob.token(), 0), &chat.HistoryRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello everyone"}, texts(res))
+	require.NotEmpty(t, res.Messages[0].GetClientLogin())
+
+	// 参加していないルームは指定しても読めない
+	_, err = c.History(outgoingContext(ctx, bob.token(), 0), &chat.HistoryRequest{Room: "dev"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	res, err = c.History(outgoingContext(ctx, alice.token(), 0), &chat.HistoryRequest{Room: "dev"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret plans"}, texts(res))
+}
+
+// 番号を本文にしたメッセージのイベントを生成する関数
+func numbered(i int) *chat.StreamResponse {
+	return &chat.StreamResponse{Event: &chat.StreamResponse_ClientMessage{ClientMessage: &chat.StreamResponse_Message{
+		Name:    "alice",
+		Message: fmt.Sprint(i),
+	}}}
+}
+
+// ストリームに溜まったイベントを送信ループと同じ順に取り出す関数
+func drain(t *testing.T, s *server, cs *clientStream) []string {
+	t.Helper()
+
+	srv := new(recordingStream)
+	for {
+		e, ok := cs.pop()
+		if !ok {
+			break
+		}
+		require.NoError(t, s.sendQueued(srv, "tkn", e))
+	}
+	var got []string
+	for _, res := range srv.sent {
+		if n := res.GetMessagesDropped().GetCount(); n > 0 {
+			got = append(got, fmt.Sprintf("dropped %d", n))
+		} else {
+			got = append(got, res.GetClientMessage().Message)
+		}
+	}
+	return got
+}
+
+// fromからto-1までの番号の文字列を返す関数
+func numbers(from, to int) []string {
+	var s []string
+	for i := from; i < to; i++ {
+		s = append(s, fmt.Sprint(i))
+	}
+	return s
+}
+
+func TestBackpressureDropNewest(t *testing.T) {
+	s := Server("bufnet", testPassword)
+	cs := newClientStream()
+	for i := 0; i < streamBufferSize+3; i++ {
+		s.deliver("tkn", cs, numbered(i))
+	}
+	require.Equal(t, 3.0, testutil.ToFloat64(s.Prometheus.dropped.WithLabelValues(dropSlowClient)))
+	got := drain(t, s, cs)
+	require.Equal(t, numbers(0, streamBufferSize), got)
+
+	// 破棄した件数は空きができた後の最初のメッセージの直前に知らせる
+	s.deliver("tkn", cs, numbered(streamBufferSize+3))
+	require.Equal(t, []string{"dropped 3", fmt.Sprint(streamBufferSize + 3)}, drain(t, s, cs))
+}
+
+func TestBackpressureDropOldest(t *testing.T) {
+	s := Server("bufnet", testPassword)
+	s.Backpressure = dropOldest
+	cs := newClientStream()
+	for i := 0; i < streamBufferSize+3; i++ {
+		s.deliver("tkn", cs, numbered(i))
+	}
+
+	// 古い方から破棄し、その件数は残ったメッセージの先頭の前に知らせる
+	want := append([]string{"dropped 3"}, numbers(3, streamBufferSize+3)...)
+	require.Equal(t, want, drain(t, s, cs))
+}
+
+func TestBackpressureDisconnect(t *testing.T) {
+	s := Server("bufnet", testPassword)
+	s.Backpressure = disconnectSlow
+	cs := newClientStream()
+	for i := 0; i < streamBufferSize; i++ {
+		s.deliver("tkn", cs, numbered(i))
+	}
+	select {
+	case <-cs.kicked:
+		t.Fatal("stream was disconnected before it was full")
+	default:
+	}
+
+	s.deliver("tkn", cs, numbered(streamBufferSize))
+	select {
+	case <-cs.kicked:
+	default:
+		t.Fatal("slow stream was not disconnected")
+	}
+}
+
+func TestBackpressureBlock(t *testing.T) {
+	s := Server("bufnet", testPassword)
+	s.Backpressure = blockWithTimeout
+	s.BlockTimeout = time.Second
+	cs := newClientStream()
+	s.ClientStreams["tkn"] = cs
+	for i := 0; i < streamBufferSize; i++ {
+		s.fanOut(numbered(i))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.fanOut(numbered(streamBufferSize))
+		close(done)
+	}()
+
+	// 空きを待つ間もストリームの登録や解放は止まらない
+	locked := make(chan struct{})
+	go func() {
+		s.streamsMtx.Lock()
+		s.streamsMtx.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(s.BlockTimeout / 2):
+		t.Fatal("fanOut held the streams lock while blocked")
+	}
+
+	// 空きができると待っていたメッセージが入る
+	_, ok := cs.pop()
+	require.True(t, ok)
+	select {
+	case <-done:
+	case <-time.After(s.BlockTimeout / 2):
+		t.Fatal("blocked message was not delivered after the stream had room")
+	}
+	got := drain(t, s, cs)
+	require.Equal(t, numbers(1, streamBufferSize+1), got)
+
+	// 空きができないまま待ち時間を過ぎると破棄し、次のメッセージの前に知らせる
+	s.BlockTimeout = 50 * time.Millisecond
+	for i := 0; i < streamBufferSize+1; i++ {
+		s.fanOut(numbered(i))
+	}
+	require.Equal(t, numbers(0, streamBufferSize), drain(t, s, cs))
+	s.fanOut(numbered(streamBufferSize + 1))
+	require.Equal(t, []string{"dropped 1", fmt.Sprint(streamBufferSize + 1)}, drain(t, s, cs))
+}
@@ -0,0 +1,207 @@
+package chatbot
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+	"grpc-chat/session"
+)
+
+// ボットの骨組み用ファイル
+// 受け取ったイベントをハンドラに振り分けて返信をリクエストとして積む、サーバとの接続はrun.goのRunが行う
+
+const (
+	// コマンドの接頭辞のデフォルト値
+	DefaultPrefix = "!"
+	// 送信を待っている返信の最大数、これを超えた返信は破棄する
+	queueSize = 100
+)
+
+// Bot dispatches chat events to registered handlers and queues the requests they send
+type Bot struct {
+	// ボット自身のユーザ名、自分の発言には反応しない
+	Name string
+	// Runでログインするときのパスワード
+	Password string
+	// Runでストリームが切断されたときに再接続を試みる連続した回数の上限、0のときは再接続しない
+	MaxReconnects int
+	// コマンドの接頭辞
+	Prefix string
+	// 診断用のログの出力先
+	Log *slog.Logger
+
+	// この時刻より前のイベント(接続時に再送される履歴)には反応しない
+	started  time.Time
+	requests chan *chat.StreamRequest
+
+	onMessage []func(*Message)
+	onLogin   []func(name string)
+	onLogout  []func(name string)
+	commands  map[string]*command
+	mtx       sync.RWMutex
+}
+
+// Message is a room or direct message the bot received
+type Message struct {
+	// 発言者、ルーム(ダイレクトメッセージのときは空)、本文
+	From, Room, Text string
+	// メッセージID、ダイレクトメッセージのときは空
+	ID string
+	// ボット宛てのダイレクトメッセージか
+	Direct bool
+	Time   time.Time
+
+	bot *Bot
+}
+
+// ボットを生成する関数
+func New(name string) *Bot {
+	b := &Bot{
+		Name:     name,
+		Prefix:   DefaultPrefix,
+		Log:      slog.Default(),
+		started:  time.Now(),
+		requests: make(chan *chat.StreamRequest, queueSize),
+		commands: make(map[string]*command),
+
+		MaxReconnects: session.DefaultMaxReconnects,
+	}
+	b.Command("help", "[command]", "list the commands or show how to use one", b.help)
+	return b
+}
+
+// メッセージを受け取ったときに呼ぶハンドラを登録する、コマンドとして処理したメッセージには呼ばない
+func (b *Bot) OnMessage(h func(*Message)) {
+	b.mtx.Lock()
+	b.onMessage = append(b.onMessage, h)
+	b.mtx.Unlock()
+}
+
+// ユーザがログインしたときに呼ぶハンドラを登録する
+func (b *Bot) OnLogin(h func(name string)) {
+	b.mtx.Lock()
+	b.onLogin = append(b.onLogin, h)
+	b.mtx.Unlock()
+}
+
+// ユーザがログアウトしたときに呼ぶハンドラを登録する
+func (b *Bot) OnLogout(h func(name string)) {
+	b.mtx.Lock()
+	b.onLogout = append(b.onLogout, h)
+	b.mtx.Unlock()
+}
+
+// 送信するリクエストを受け取るチャネルを返す、クライアントのストリームに送る
+func (b *Bot) Requests() <-chan *chat.StreamRequest {
+	return b.requests
+}
+
+// 受け取ったイベントを登録されたハンドラに振り分ける、クライアントがイベントを受け取るたびに呼ぶ
+// ハンドラは受信と同じゴルーチンで呼ぶので、時間のかかる処理は別のゴルーチンで行うこと
+func (b *Bot) HandleEvent(res *chat.StreamResponse) {
+	if res.Timestamp != nil && res.Timestamp.AsTime().Before(b.started) {
+		return
+	}
+
+	b.mtx.RLock()
+	onMessage, onLogin, onLogout := b.onMessage, b.onLogin, b.onLogout
+	b.mtx.RUnlock()
+
+	switch evt := res.Event.(type) {
+	case *chat.StreamResponse_ClientLogin:
+		if evt.ClientLogin.Name != b.Name {
+			for _, h := range onLogin {
+				h(evt.ClientLogin.Name)
+			}
+		}
+	case *chat.StreamResponse_ClientLogout:
+		if evt.ClientLogout.Name != b.Name {
+			for _, h := range onLogout {
+				h(evt.ClientLogout.Name)
+			}
+		}
+	case *chat.StreamResponse_ClientMessage:
+		m := evt.ClientMessage
+		b.handleMessage(onMessage, &Message{From: m.Name, Room: m.Room, Text: m.Message, ID: m.Id, Time: res.Timestamp.AsTime(), bot: b})
+	case *chat.StreamResponse_DirectMessage:
+		dm := evt.DirectMessage
+		if dm.To != b.Name {
+			return
+		}
+		// ボットは鍵を持たないので暗号化されたメッセージは読めない
+		if dm.Sealed != nil {
+			b.Direct(dm.From, "I cannot read encrypted messages, please use /msg")
+			return
+		}
+		b.handleMessage(onMessage, &Message{From: dm.From, Text: dm.Message, Direct: true, Time: res.Timestamp.AsTime(), bot: b})
+	}
+}
+
+// 自分以外の発言をコマンドかメッセージのハンドラに渡すメソッド
+func (b *Bot) handleMessage(onMessage []func(*Message), m *Message) {
+	if m.From == b.Name {
+		return
+	}
+	if b.dispatch(m) {
+		return
+	}
+	for _, h := range onMessage {
+		h(m)
+	}
+}
+
+// ルームに発言する、roomが空のときはデフォルトのルーム
+func (b *Bot) Say(room, text string) {
+	b.send(&chat.StreamRequest{Room: room, Message: text})
+}
+
+// ユーザにダイレクトメッセージを送る
+func (b *Bot) Direct(to, text string) {
+	b.send(&chat.StreamRequest{Recipient: to, Message: text})
+}
+
+// リクエストを送信待ちに積むメソッド、切断中などで溜まりすぎたときは古いハンドラを止めないよう破棄する
+func (b *Bot) send(req *chat.StreamRequest) {
+	select {
+	case b.requests <- req:
+	default:
+		b.Log.Warn("request queue is full, dropping reply", "room", req.Room, "recipient", req.Recipient)
+	}
+}
+
+// メッセージの送信者に返信する、ダイレクトメッセージにはダイレクトメッセージで、ルームの発言には同じルームで返す
+func (m *Message) Reply(text string) {
+	if m.Direct {
+		m.bot.Direct(m.From, text)
+		return
+	}
+	m.bot.Say(m.Room, text)
+}
+
+// コマンドの一覧か、指定したコマンドの使い方を返す組み込みのコマンド
+func (b *Bot) help(m *Message, args Args) error {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	if name := strings.TrimPrefix(args.String("command"), b.Prefix); name != "" {
+		cmd, ok := b.commands[name]
+		if !ok {
+			m.Reply("unknown command " + b.Prefix + name)
+			return nil
+		}
+		m.Reply(b.usage(cmd) + ": " + cmd.help)
+		return nil
+	}
+
+	names := make([]string, 0, len(b.commands))
+	for name := range b.commands {
+		names = append(names, b.Prefix+name)
+	}
+	sort.Strings(names)
+	m.Reply("commands: " + strings.Join(names, ", ") + " (" + b.Prefix + "help <command> for details)")
+	return nil
+}
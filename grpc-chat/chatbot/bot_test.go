@@ -0,0 +1,97 @@
+package chatbot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ルームへの発言のイベントを生成する関数
+func roomMessage(from, room, text string) *chat.StreamResponse {
+	return &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_ClientMessage{
+			ClientMessage: &chat.StreamResponse_Message{Name: from, Room: room, Message: text},
+		},
+	}
+}
+
+// 積まれたリクエストを全て取り出す関数
+func drain(b *Bot) []*chat.StreamRequest {
+	var reqs []*chat.StreamRequest
+	for {
+		select {
+		case req := <-b.Requests():
+			reqs = append(reqs, req)
+		default:
+			return reqs
+		}
+	}
+}
+
+func TestBotDispatch(t *testing.T) {
+	b := New("bot")
+	var messages, logins, logouts []string
+	b.OnMessage(func(m *Message) { messages = append(messages, m.From+": "+m.Text) })
+	b.OnLogin(func(name string) { logins = append(logins, name) })
+	b.OnLogout(func(name string) { logouts = append(logouts, name) })
+	b.Command("greet", "<name> [greeting...]", "greet someone", func(m *Message, args Args) error {
+		greeting := args.String("greeting")
+		if greeting == "" {
+			greeting = "hello"
+		}
+		m.Reply(greeting + ", " + args.String("name"))
+		return nil
+	})
+	b.Command("fail", "", "always fails", func(*Message, Args) error { return errors.New("boom") })
+
+	b.HandleEvent(&chat.StreamResponse{Timestamp: timestamppb.Now(), Event: &chat.StreamResponse_ClientLogin{ClientLogin: &chat.StreamResponse_Login{Name: "alice"}}})
+	b.HandleEvent(roomMessage("alice", "dev", "hi all"))
+	b.HandleEvent(roomMessage("alice", "dev", "!greet bob good morning"))
+	b.HandleEvent(roomMessage("alice", "dev", "!greet"))
+	b.HandleEvent(roomMessage("alice", "dev", "!fail"))
+	b.HandleEvent(roomMessage("alice", "dev", "!nope"))
+	b.HandleEvent(&chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_DirectMessage{
+			DirectMessage: &chat.StreamResponse_Direct{From: "carol", To: "bot", Message: "!greet carol"},
+		},
+	})
+	b.HandleEvent(&chat.StreamResponse{Timestamp: timestamppb.Now(), Event: &chat.StreamResponse_ClientLogout{ClientLogout: &chat.StreamResponse_Logout{Name: "alice"}}})
+
+	// 自分の発言や、起動前の履歴には反応しない
+	b.HandleEvent(roomMessage("bot", "dev", "!greet me"))
+	old := roomMessage("alice", "dev", "!greet old")
+	old.Timestamp = timestamppb.New(time.Now().Add(-time.Hour))
+	b.HandleEvent(old)
+
+	require.Equal(t, []string{"alice: hi all"}, messages)
+	require.Equal(t, []string{"alice"}, logins)
+	require.Equal(t, []string{"alice"}, logouts)
+
+	reqs := drain(b)
+	require.Len(t, reqs, 5)
+	require.Equal(t, &chat.StreamRequest{Room: "dev", Message: "good morning, bob"}, reqs[0])
+	require.Equal(t, "usage: !greet <name> [greeting...]", reqs[1].Message)
+	require.Equal(t, "!fail: boom", reqs[2].Message)
+	require.Equal(t, "unknown command !nope, try !help", reqs[3].Message)
+	// ダイレクトメッセージにはダイレクトメッセージで返す
+	require.Equal(t, &chat.StreamRequest{Recipient: "carol", Message: "hello, carol"}, reqs[4])
+}
+
+func TestBotHelp(t *testing.T) {
+	b := New("bot")
+	b.Command("roll", "[dice]", "roll dice", func(*Message, Args) error { return nil })
+
+	b.HandleEvent(roomMessage("alice", "", "!help"))
+	b.HandleEvent(roomMessage("alice", "", "!help !roll"))
+	reqs := drain(b)
+	require.Len(t, reqs, 2)
+	require.Equal(t, "commands: !help, !roll (!help <command> for details)", reqs[0].Message)
+	require.Equal(t, "!roll [dice]: roll dice", reqs[1].Message)
+}
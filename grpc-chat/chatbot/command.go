@@ -0,0 +1,183 @@
+package chatbot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// コマンドの登録と引数の解析用ファイル
+// 使い方は"<name>"(必須)、"[name]"(省略可)、末尾の"<name...>"(残りの全て)を空白区切りで並べて書く
+
+// ErrUsage can be returned by a command to reply with its usage
+var ErrUsage = errors.New("invalid arguments")
+
+// CommandFunc handles a command with its parsed arguments; a returned error is sent back to the caller
+type CommandFunc func(m *Message, args Args) error
+
+// Args holds the arguments of a command by the names in its usage
+type Args map[string]string
+
+// 登録されたコマンド
+type command struct {
+	name, help string
+	params     []param
+	fn         CommandFunc
+}
+
+// コマンドの1つの引数
+type param struct {
+	name string
+	// 省略できるか、残りの入力を全て受け取るか
+	optional, rest bool
+}
+
+// コマンドを登録する、usageの書式が正しくないときはpanicする
+// 同じ名前のコマンドを登録したときは後から登録したものに置き換える
+func (b *Bot) Command(name, usage, help string, fn CommandFunc) {
+	params, err := parseUsage(usage)
+	if err != nil {
+		panic(fmt.Sprintf("chatbot: command %q: %v", name, err))
+	}
+	b.mtx.Lock()
+	b.commands[name] = &command{name: name, help: help, params: params, fn: fn}
+	b.mtx.Unlock()
+}
+
+// 使い方の書式を解析する関数
+func parseUsage(usage string) ([]param, error) {
+	var params []param
+	seen := make(map[string]bool)
+	for i, f := range strings.Fields(usage) {
+		var p param
+		switch {
+		case strings.HasPrefix(f, "<") && strings.HasSuffix(f, ">"):
+		case strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]"):
+			p.optional = true
+		default:
+			return nil, fmt.Errorf("argument %q must be written as <name> or [name]", f)
+		}
+		p.name = f[1 : len(f)-1]
+		if p.name, p.rest = strings.CutSuffix(p.name, "..."); p.rest && i != len(strings.Fields(usage))-1 {
+			return nil, fmt.Errorf("only the last argument can take the rest of the input")
+		}
+		switch {
+		case p.name == "":
+			return nil, fmt.Errorf("argument %q has no name", f)
+		case seen[p.name]:
+			return nil, fmt.Errorf("argument %q is declared twice", p.name)
+		case !p.optional && len(params) > 0 && params[len(params)-1].optional:
+			return nil, fmt.Errorf("required argument %q follows an optional one", p.name)
+		}
+		seen[p.name] = true
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// 入力をコマンドの引数に割り当てるメソッド、"で囲んだ部分は空白を含めて1つの引数にする
+func (c *command) parse(input string) (Args, error) {
+	args := make(Args, len(c.params))
+	rest := strings.TrimSpace(input)
+	for _, p := range c.params {
+		if rest == "" {
+			if !p.optional {
+				return nil, ErrUsage
+			}
+			break
+		}
+		if p.rest {
+			args[p.name], rest = rest, ""
+			break
+		}
+		var (
+			field string
+			err   error
+		)
+		if field, rest, err = nextField(rest); err != nil {
+			return nil, err
+		}
+		args[p.name] = field
+	}
+	if rest != "" {
+		return nil, ErrUsage
+	}
+	return args, nil
+}
+
+// 先頭の引数とそれ以降の入力を返す関数
+func nextField(s string) (field, rest string, err error) {
+	if !strings.HasPrefix(s, `"`) {
+		field, rest, _ = strings.Cut(s, " ")
+		return field, strings.TrimSpace(rest), nil
+	}
+	end := strings.Index(s[1:], `"`)
+	if end < 0 {
+		return "", "", ErrUsage
+	}
+	return s[1 : end+1], strings.TrimSpace(s[end+2:]), nil
+}
+
+// コマンドの使い方を返すメソッド
+func (b *Bot) usage(c *command) string {
+	parts := []string{b.Prefix + c.name}
+	for _, p := range c.params {
+		name := p.name
+		if p.rest {
+			name += "..."
+		}
+		if p.optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// 接頭辞で始まるメッセージをコマンドとして実行するメソッド、コマンドとして処理した場合はtrueを返す
+func (b *Bot) dispatch(m *Message) bool {
+	if b.Prefix == "" || !strings.HasPrefix(m.Text, b.Prefix) {
+		return false
+	}
+	name, input, _ := strings.Cut(strings.TrimPrefix(m.Text, b.Prefix), " ")
+	b.mtx.RLock()
+	cmd, ok := b.commands[name]
+	b.mtx.RUnlock()
+	if !ok {
+		m.Reply(fmt.Sprintf("unknown command %s%s, try %shelp", b.Prefix, name, b.Prefix))
+		return true
+	}
+
+	args, err := cmd.parse(input)
+	if err == nil {
+		err = cmd.fn(m, args)
+	}
+	switch {
+	case errors.Is(err, ErrUsage):
+		m.Reply("usage: " + b.usage(cmd))
+	case err != nil:
+		b.Log.Debug("command failed", "command", name, "from", m.From, "err", err)
+		m.Reply(fmt.Sprintf("%s%s: %v", b.Prefix, name, err))
+	}
+	return true
+}
+
+// 引数を返す、省略されたときは空文字列
+func (a Args) String(name string) string {
+	return a[name]
+}
+
+// 引数を整数として返す、省略されたときはdefを返す、整数でないときはErrUsageを返す
+func (a Args) Int(name string, def int) (int, error) {
+	v, ok := a[name]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", name, ErrUsage)
+	}
+	return n, nil
+}
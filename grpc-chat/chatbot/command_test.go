@@ -0,0 +1,52 @@
+package chatbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUsage(t *testing.T) {
+	params, err := parseUsage("<to> [count] [text...]")
+	require.NoError(t, err)
+	require.Equal(t, []param{{name: "to"}, {name: "count", optional: true}, {name: "text", optional: true, rest: true}}, params)
+
+	for _, usage := range []string{"name", "<>", "<a> <a>", "[a] <b>", "<a...> <b>"} {
+		_, err := parseUsage(usage)
+		require.Error(t, err, usage)
+	}
+	require.Panics(t, func() { New("bot").Command("bad", "[a] <b>", "", nil) })
+}
+
+func TestCommandParse(t *testing.T) {
+	params, err := parseUsage("<to> [count] [text...]")
+	require.NoError(t, err)
+	cmd := &command{name: "send", params: params}
+
+	args, err := cmd.parse(`alice 3 hello   there`)
+	require.NoError(t, err)
+	require.Equal(t, Args{"to": "alice", "count": "3", "text": "hello   there"}, args)
+
+	// "で囲んだ部分は1つの引数になる
+	args, err = cmd.parse(`"alice smith"`)
+	require.NoError(t, err)
+	require.Equal(t, Args{"to": "alice smith"}, args)
+	n, err := args.Int("count", 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = cmd.parse("")
+	require.ErrorIs(t, err, ErrUsage)
+	_, err = cmd.parse(`"alice`)
+	require.ErrorIs(t, err, ErrUsage)
+
+	args, err = cmd.parse("alice many")
+	require.NoError(t, err)
+	_, err = args.Int("count", 1)
+	require.ErrorIs(t, err, ErrUsage)
+
+	// 引数が余ったときは使い方を返す
+	cmd = &command{name: "ping"}
+	_, err = cmd.parse("extra")
+	require.ErrorIs(t, err, ErrUsage)
+}
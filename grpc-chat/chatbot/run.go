@@ -0,0 +1,82 @@
+package chatbot
+
+import (
+	"io"
+
+	chat "grpc-chat/protos"
+	"grpc-chat/session"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// サーバとの接続用ファイル
+// ログインや再接続、トークンの更新、ログアウトは端末のクライアントと同じくsessionパッケージが行い、ここではストリームのイベントをボットに渡す
+
+// Run はconnで接続したサーバにNameとPasswordでログインし、ctxが終了するまでイベントをハンドラに渡して返信を送り続ける
+// ストリームが切断されたときは再接続し、終了するときにログアウトする、connは呼び出し側で閉じる
+func (b *Bot) Run(ctx context.Context, conn grpc.ClientConnInterface) error {
+	s := session.New(b.Name, b.Password)
+	s.Client = chat.NewChatClient(conn)
+	s.MaxReconnects = b.MaxReconnects
+	s.Log = b.Log
+	return s.Run(ctx, func(ctx context.Context) (bool, error) { return b.stream(ctx, s) })
+}
+
+// ストリームを開き、切断されるまでイベントをボットに渡すメソッド、ストリームを開けたかどうかも返す
+func (b *Bot) stream(ctx context.Context, s *session.Session) (established bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sc, err := s.Client.Stream(ctx)
+	if err != nil {
+		return false, err
+	}
+	// サーバが認証を終えてストリームを受け付けるとヘッダが届く
+	if _, err := sc.Header(); err != nil {
+		return false, err
+	}
+	b.Log.Debug("connected to stream")
+
+	go b.forward(sc)
+	for {
+		res, err := sc.Recv()
+		switch {
+		case err == io.EOF || status.Code(err) == codes.Canceled:
+			return established, nil
+		case err != nil:
+			return established, err
+		}
+		established = true
+
+		s.Received(res.Sequence)
+		b.HandleEvent(res)
+
+		switch evt := res.Event.(type) {
+		case *chat.StreamResponse_ClientKicked:
+			b.Log.Info("removed from the chat", "reason", evt.ClientKicked.Reason)
+			return established, session.ErrEnded
+		case *chat.StreamResponse_ServerShutdown:
+			// 再起動したサーバが保存したセッションを再開できるよう、ログアウトせずに再接続する
+			b.Log.Info("the server is shutting down")
+			return established, nil
+		}
+	}
+}
+
+// ハンドラが積んだリクエストをストリームが閉じるまで送り続けるメソッド
+func (b *Bot) forward(sc chat.Chat_StreamClient) {
+	for {
+		select {
+		case <-sc.Context().Done():
+			return
+		case req := <-b.requests:
+			if err := sc.Send(req); err != nil {
+				b.Log.Warn("failed to send request", "err", err)
+				return
+			}
+		}
+	}
+}
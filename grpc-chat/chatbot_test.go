@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"grpc-chat/chatbot"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestChatbotRun(t *testing.T) {
+	ts := startServer(t)
+
+	bot := chatbot.New("echobot")
+	bot.Password = testPassword
+	bot.Log = logger.With("component", "bot")
+	bot.Command("echo", "<text...>", "repeat the text back", func(m *chatbot.Message, args chatbot.Args) error {
+		m.Reply(args.String("text"))
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- bot.Run(ctx, ts.conn(t)) }()
+	require.Eventually(t, func() bool { return ts.isOnline("echobot") }, testTimeout, 10*time.Millisecond)
+
+	alice := ts.startClient(t, "alice")
+	alice.say(t, "!echo hello   bot")
+	alice.expect(t, "the echo", messageFrom("echobot", "hello   bot"))
+
+	// 終了するとログアウトし、名前を空ける
+	cancel()
+	require.NoError(t, <-done)
+	alice.expect(t, "the bot's logout", logoutOf("echobot"))
+	require.Empty(t, ts.getTokens("echobot"))
+}
@@ -2,33 +2,97 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	"io"
+	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
-	chat "github.com/rodaine/grpc-chat/protos"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"grpc-chat/e2e"
+	chat "grpc-chat/protos"
+	"grpc-chat/session"
 )
 
 // クライアントのデータを受け取るためのデータ処理を実現するためのデータ群を格納して各処理を行うために使用
 type client struct {
 	chat.ChatClient
-	Host, Password, Name, Token string
-	Shutdown                    bool
+	Host, Password, Name string
+	// ログインからログアウトまでのトークンの管理と再接続、ボットと共通
+	sess *session.Session
+	// 発言先の現在のルーム
+	Room string
+	// サーバからシャットダウンの通知を受け取った、再起動を待って再接続する
+	Shutdown bool
+	// モデレータによってセッションを終了させられた、再接続もログアウトもしない
+	Kicked bool
+	// ログインの前にNameとPasswordでアカウントを登録する
+	Register bool
+	// TLSの認証情報、nilのときは平文で通信する
+	Creds credentials.TransportCredentials
+	// ストリームが切断されたときに再接続を試みる連続した回数の上限、0のときは再接続しない
+	MaxReconnects int
+	// 送信するメッセージやコマンドの入力元、nilのときは標準入力
+	Input io.Reader
+	// 受け取ったイベントを表示の前に渡す関数、nilのときは何もしない
+	OnEvent func(res *chat.StreamResponse)
+	// TUIの入力欄の操作から入力中の通知を送るかを判断し、送る通知を送信ループに渡す
+	// 行単位の入力では端末が改行まで入力を渡さず打鍵が分からないので、TUIでだけ使う
+	typing      *typingNotifier
+	typingState chan bool
+	// 前回の検索の次のページのリクエスト、続きがないときはnil
+	nextSearch *chat.SearchRequest
+	// 接続時に追加するオプション、インメモリの接続を使うときなどに指定する
+	DialOptions []grpc.DialOption
+	// 受け取ったメッセージの表示先、編集やリアクションがあったときに描き直す
+	Transcript *transcript
+	// 診断用のログの出力先
+	Log *slog.Logger
+	// ダイレクトメッセージをエンドツーエンドで暗号化するための鍵ペア、公開鍵はログイン時にサーバへ公開する
+	Keys *e2e.KeyPair
+	// ユーザ名をキーとして、暗号化したメッセージをやり取りした相手の公開鍵を保持
+	peerKeys map[string][]byte
+
+	keysMtx sync.Mutex
 }
 
 // 構造体clientを生成するメソッド
 func Client(host, pass, name string) *client {
-	return &client{
+	// 鍵ペアは乱数から作るだけなので生成に失敗しない
+	keys, _ := e2e.GenerateKey()
+
+	c := &client{
 		Host:     host,
 		Password: pass,
 		Name:     name,
+		Room:     defaultRoom,
+
+		MaxReconnects: session.DefaultMaxReconnects,
+		sess:          session.New(name, pass),
+		Transcript:    newTranscript(log.Writer()),
+		Log:           logger.With("component", "client", "name", name),
+		Keys:          keys,
+		peerKeys:      make(map[string][]byte),
+		typingState:   make(chan bool, 16),
 	}
+	// 送信ループが詰まっているときは通知を諦め、入力を止めない
+	c.typing = newTypingNotifier(typingIdle, func(start bool) {
+		select {
+		case c.typingState <- start:
+		default:
+		}
+	})
+	return c
 }
 
 // 【確認】
@@ -36,14 +100,19 @@ func Client(host, pass, name string) *client {
 // ストリーム：コネクションを利用して実際にデータを送受信するための構造、gRPCでは、このストリームを使って複数のメッセージを効率的に、かつ順序良く送受信することができる、ストリームは、単一のコネクション上で複数同時に存在することができ、それぞれ独立したメッセージの流れを管理する、加えて、各ストリームは、メッセージの送信方向と受信方向の両方を持ち、双方向の通信が可能。クライアントがサーバーにメッセージを送信すると同時に、サーバーからのメッセージも受信できる。よって、リアルタイムの通信や、状態の継続的な同期が必要なアプリケーションに活用される
 // 【gRPCのストリーム】HTTP/2プロトコル上で実装されている、HTTP/2は、単一のTCPコネクション上に複数のストリーム（このコンテキストで言えば、独立した通信チャネル）を同時に開くことをサポートしているため、一つのコネクション上で複数のリクエストとレスポンスを交互に、または並行して処理することが可能になる
 
-// クライアントがサーバーとの通信を確立し、ログインしてメッセージの送受信を行い、最終的にログアウトするまでのプロセスを管理するメソッド、実際の通信処理は、内部で呼び出したlogin, stream, logout の各メソッド呼び出しを通じて間接的に実行される
+// クライアントがサーバーとの通信を確立し、ログインしてメッセージの送受信を行い、最終的にログアウトするまでのプロセスを管理するメソッド、ログインや再接続、ログアウトはsessionパッケージが行い、ストリームの処理はstreamメソッドが行う
 func (c *client) Run(ctx context.Context) error {
 	// タイムアウトを1秒に設定するコンテキストを付与したコネクションのインスタンスの生成
 	connCtx, cancel := context.WithTimeout(ctx, time.Second)
 
 	defer cancel()
 	// 非ブロッキングではなくブロッキングモードを選択することで、接続の準備が整うまで（今回は最大1秒間）待機、その期間内に接続が確立されれば処理を続行し、確立されなければエラーを返して後続のエラーハンドリングにより予期しない挙動を防ぐ、Dial:接続を確立する、Context:コンテキストを使用して操作を制御する
-	conn, err := grpc.DialContext(connCtx, c.Host, grpc.WithInsecure(), grpc.WithBlock()) // 本来はInsecureを使用するべきでないがここではこのままにしておく、本番環境はセキュアにすること
+	creds := c.Creds
+	if creds == nil {
+		creds = insecure.NewCredentials() // TLSの設定が無いときは平文、共有のネットワークでは-tls-caなどを指定すること
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds), grpc.WithBlock()}, c.DialOptions...)
+	conn, err := grpc.DialContext(connCtx, c.Host, opts...)
 	if err != nil {
 		return errors.WithMessage(err, "failed to connect to server")
 	}
@@ -51,31 +120,31 @@ func (c *client) Run(ctx context.Context) error {
 
 	c.ChatClient = chat.NewChatClient(conn)
 
-	if c.Token, err = c.login(ctx); err != nil {
-		return errors.WithMessage(err, "failed to login")
+	if c.Register {
+		if err = c.register(ctx); err != nil {
+			return errors.WithMessage(err, "failed to register")
+		}
+		ClientNotef(time.Now(), "registered successfully")
 	}
-	ClientLogf(time.Now(), "logged in successfully")
 
-	// サーバーとの双方向通信を管理するストリームを開始して、その接続（コネクション）上でメッセージのやり取りを行うための準備をする
-	err = c.stream(ctx)
-
-	ClientLogf(time.Now(), "logging out")
-	if err := c.logout(ctx); err != nil {
-		ClientLogf(time.Now(), "failed to logout: %v", err)
+	// 入力は再接続をまたいで1つのゴルーチンで読み続ける
+	input := c.Input
+	if input == nil {
+		input = os.Stdin
 	}
+	lines := readLines(input)
 
-	return errors.WithMessage(err, "stream error")
+	// ログインしてサーバーとの双方向通信を管理するストリームを開始し、その接続（コネクション）上でメッセージのやり取りを行う
+	// ストリームが途中で切れたときは、待ち時間を伸ばしながら再接続する
+	c.sess.Client, c.sess.Name, c.sess.Password, c.sess.PublicKey = c.ChatClient, c.Name, c.Password, c.Keys.Public
+	c.sess.MaxReconnects, c.sess.Log = c.MaxReconnects, c.Log
+	c.sess.Notify = func(format string, args ...any) { ClientNotef(time.Now(), format, args...) }
+	return c.sess.Run(ctx, func(ctx context.Context) (bool, error) { return c.stream(ctx, lines) })
 }
 
-// Runメソッドの内部の各メソッドの実装
-// 抽象がビジネス的詳細に依存する形になっている
-
-// サーバとの双方向ストリームを開始し、メッセージの送受信を管理するメソッド
-func (c *client) stream(ctx context.Context) error {
-	// トークンの抽出
-	md := metadata.New(map[string]string{"token": c.Token})
-	// トークンを含むメタデータを付与して新しいコンテキストを生成
-	ctx = metadata.NewOutgoingContext(ctx, md)
+// サーバとの双方向ストリームを開始し、メッセージの送受信を管理するメソッド、ストリームを開けたかどうかも返す
+// ctxにはトークンと、再接続のときは最後に受け取ったシーケンス番号がメタデータとして付与されている
+func (c *client) stream(ctx context.Context, lines <-chan string) (bool, error) {
 	// ctxに基づいて新しいコンテキストとキャンセル関数を生成
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -83,33 +152,45 @@ func (c *client) stream(ctx context.Context) error {
 	// サーバとの双方向通信チャネル(ストリーム)を開始
 	client, err := c.ChatClient.Stream(ctx)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer client.CloseSend()
 
-	ClientLogf(time.Now(), "connected to stream")
-
 	// c.sendメソッドを新しいゴルーチンで実行；非同期にクライアントからサーバーへのメッセージ送信処理をする；１つ下のsendメソッド参照
-	go c.send(client)
+	go c.send(client, lines)
 	// c.receive()：サーバーからのメッセージを受信、処理する、この同期実行により、受信処理がメインの実行フローとなり、サーバーからのメッセージがなくなるか、何らかのエラーが発生するまで処理が続行
 	return c.receive(client)
 }
 
 // クライアントがサーバーからのストリーミング応答をリアルタイムで受信し、それぞれのメッセージタイプに基づいて適切なアクションを実行するためのメソッド
-func (c *client) receive(sc chat.Chat_StreamClient) error {
+// ストリームの確立はヘッダかイベントを最初に受け取った時点で判断する
+func (c *client) receive(sc chat.Chat_StreamClient) (established bool, err error) {
+	// サーバが認証を終えてストリームを受け付けるとヘッダが届く
+	if _, err := sc.Header(); err != nil {
+		return false, err
+	}
+	ClientNotef(time.Now(), "connected to stream")
+
 	for {
 		// recv:receive;gRPCのストリームから次のメッセージを受信し、それを返すブロッキングプロセス、新しいメッセージが到着するまでルーチンの処理進行を停止
 		res, err := sc.Recv()
 
 		// FromError:errがnilでないときそのエラーがgRPCのステータスエラーであるかどうかをチェック、okがtrueになる：errが実際にgRPCのステータスエラーでstatus.FromError(err)が有効なstatus.Statusオブジェクトを抽出できたとき
 		if s, ok := status.FromError(err); ok && s.Code() == codes.Canceled {
-			DebugLogf("stream canceled (usually indicates shutdown)")
-			return nil
+			c.Log.Debug("stream canceled (usually indicates shutdown)")
+			return established, nil
 		} else if err == io.EOF {
-			DebugLogf("stream closed by server") // EOF:End of File;ストリームやファイルの終わりを示す。通信プロトコ・データストリームのコンテキストではデータの送信元がこれ以上送信するデータがないことを受信側に伝えるために使用
-			return nil
+			c.Log.Debug("stream closed by server") // EOF:End of File;ストリームやファイルの終わりを示す。通信プロトコ・データストリームのコンテキストではデータの送信元がこれ以上送信するデータがないことを受信側に伝えるために使用
+			return established, nil
 		} else if err != nil {
-			return err
+			return established, err
+		}
+		established = true
+
+		// 再接続したときに続きから受け取れるよう、受け取った番号を覚えておく
+		c.sess.Received(res.Sequence)
+		if c.OnEvent != nil {
+			c.OnEvent(res)
 		}
 
 		// ts:time stamp;メッセージの送受信時刻を保持
@@ -118,79 +199,324 @@ func (c *client) receive(sc chat.Chat_StreamClient) error {
 		// evt:event type;l:102のメッセージの種類を保持
 		switch evt := res.Event.(type) {
 		case *chat.StreamResponse_ClientLogin:
-			ServerLogf(ts, "%s has logged in", evt.ClientLogin.Name)
+			ServerNotef(ts, "%s has logged in", evt.ClientLogin.Name)
 		case *chat.StreamResponse_ClientLogout:
-			ServerLogf(ts, "%s has logged out", evt.ClientLogout.Name)
+			ServerNotef(ts, "%s has logged out", evt.ClientLogout.Name)
 		// クライアントからのメッセージイベント。メッセージを送信したクライアントの名前とメッセージ内容をログに記録
 		case *chat.StreamResponse_ClientMessage:
-			MessageLog(ts, evt.ClientMessage.Name, evt.ClientMessage.Message)
+			c.Transcript.message(ts, evt.ClientMessage)
+		// 編集・削除・リアクションは元のメッセージの行に反映する
+		case *chat.StreamResponse_MessageEdited:
+			c.Transcript.edited(evt.MessageEdited)
+		case *chat.StreamResponse_MessageDeleted:
+			c.Transcript.deleted(evt.MessageDeleted)
+		case *chat.StreamResponse_MessageReaction:
+			c.Transcript.reacted(evt.MessageReaction)
+		case *chat.StreamResponse_FileAttachment:
+			MessageLog(ts, roomName(evt.FileAttachment.Room, evt.FileAttachment.Name), attachmentText(evt.FileAttachment))
+		case *chat.StreamResponse_DirectMessage:
+			c.directMessage(sc.Context(), ts, evt.DirectMessage)
+		case *chat.StreamResponse_Typing:
+			if evt.Typing.Name != c.Name {
+				ClientNotef(ts, "%s is typing...", roomName(evt.Typing.Room, evt.Typing.Name))
+			}
+		case *chat.StreamResponse_StoppedTyping:
+			// 行単位の表示では入力中の表示を消せないので何もしない
+		case *chat.StreamResponse_MessagesDropped:
+			ClientNotef(ts, "-- %d message(s) were dropped because this client fell behind --", evt.MessagesDropped.Count)
+		case *chat.StreamResponse_ServerError:
+			ClientNotef(ts, "server error: %s", evt.ServerError.Message)
+		case *chat.StreamResponse_ModerationAction:
+			ServerNotef(ts, "%s", moderationText(evt.ModerationAction))
+		case *chat.StreamResponse_ClientKicked:
+			ServerNotef(ts, "you have been removed from the chat: %s", evt.ClientKicked.Reason)
+			c.Kicked = true
+			return established, session.ErrEnded
+		case *chat.StreamResponse_ServerAnnouncement:
+			ServerNotef(ts, "%s", roomName(evt.ServerAnnouncement.Room, "announcement from "+evt.ServerAnnouncement.From+": "+evt.ServerAnnouncement.Message))
 		case *chat.StreamResponse_ServerShutdown:
-			ServerLogf(ts, "the server is shutting down")
-			// クライアントがサーバーからシャットダウン通知を受け取ったことを示し、クライアント側で適切な処理を行うためのフラグをセット；クライアントはサーバーが既にシャットダウンしていることを認識し、それに応じた処理（例えば、さらなるリクエストの送信を停止する、リソースのクリーンアップを行うなど）を行う
+			if n := evt.ServerShutdown.GraceSeconds; n > 0 {
+				ServerNotef(ts, "the server is shutting down (closing in %d seconds)", n)
+			} else {
+				ServerNotef(ts, "the server is shutting down")
+			}
+			// サーバが-sessionsで保存したセッションを再起動後に再開できるよう、トークンと受け取った番号を残したまま再接続する
 			c.Shutdown = true
-			return nil
+			return established, nil
 		default:
-			ClientLogf(ts, "unexpected event from the server: %T", evt)
-			return nil
+			ClientNotef(ts, "unexpected event from the server: %T", evt)
+			return established, nil
 		}
 	}
 }
 
-// ユーザーからのテキストメッセージをリアルタイムで収集、開かれたgRPCストリームを通じてサーバーに送信するメソッド
-func (c *client) send(client chat.Chat_StreamClient) {
-	sc := bufio.NewScanner(os.Stdin) // 標準入力(os.Stdin)からテキストを読み取るためのスキャナーを作成
-	sc.Split(bufio.ScanLines)        // ユーザからの入力を行単位で分割して扱うようにスキャナーに設定
+// 入力を行単位で読み取り、チャネルに送り続ける関数、入力が終わるとチャネルを閉じる
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
 
+		sc := bufio.NewScanner(r) // 入力(通常は標準入力)からテキストを読み取るためのスキャナーを作成
+		sc.Split(bufio.ScanLines) // ユーザからの入力を行単位で分割して扱うようにスキャナーに設定
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		ClientNotef(time.Now(), "input scanner failure: %v", sc.Err())
+	}()
+	return lines
+}
+
+// ユーザーからのテキストメッセージをリアルタイムで収集、開かれたgRPCストリームを通じてサーバーに送信するメソッド
+func (c *client) send(client chat.Chat_StreamClient, lines <-chan string) {
 	for {
 		select {
 		case <-client.Context().Done():
-			DebugLogf("client send loop disconnected")
-		default:
-			if sc.Scan() {
-				if err := client.Send(&chat.StreamRequest{Message: sc.Text()}); err != nil {
-					ClientLogf(time.Now(), "failed to send message: %v", err)
-					return
-				}
-			} else {
-				ClientLogf(time.Now(), "input scanner failure: %v", sc.Err())
+			c.Log.Debug("client send loop disconnected")
+			return
+		case start := <-c.typingState:
+			if err := client.Send(typingRequest(start, c.Room)); err != nil {
+				ClientNotef(time.Now(), "failed to send typing state: %v", err)
+				return
+			}
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			c.Transcript.inputLine()
+			// "/"で始まる入力はクライアントのコマンドとして処理
+			if c.command(client, line) {
+				continue
+			}
+			if err := client.Send(&chat.StreamRequest{Message: line, Room: c.Room}); err != nil {
+				ClientNotef(time.Now(), "failed to send message: %v", err)
 				return
 			}
 		}
 	}
 }
 
-// クライアントのログイン処理を担うメソッド
-func (c *client) login(ctx context.Context) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second)
+// "/join <room>"、"/leave"、"/rooms"、"/history [n]"、"/who"、"/search <query>"、"/msg <name> <text>"、"/secret <name> <text>"、"/send <path>"などのコマンドを処理するメソッド、コマンドとして処理した場合はtrueを返す、ここで処理しないコマンド("/kick"など)はそのままサーバへ送る
+func (c *client) command(client chat.Chat_StreamClient, line string) bool {
+	if !strings.HasPrefix(line, "/") {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	res, err := c.ChatClient.Login(ctx, &chat.LoginRequest{
-		Name:     c.Name,
-		Password: c.Password,
-	})
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case "/join":
+		if arg == "" {
+			ClientNotef(time.Now(), "usage: /join <room>")
+			return true
+		}
+		room := normalizeRoom(arg)
+		_, err := c.ChatClient.JoinRoom(ctx, &chat.JoinRoomRequest{Token: c.token(), Room: room})
+		if s, ok := status.FromError(err); err != nil && !(ok && s.Code() == codes.AlreadyExists) {
+			ClientNotef(time.Now(), "failed to join room %q: %v", room, err)
+			return true
+		}
+		c.Room = room
+		ClientNotef(time.Now(), "now talking in #%s", room)
+	case "/leave":
+		if _, err := c.ChatClient.LeaveRoom(ctx, &chat.LeaveRoomRequest{Token: c.token(), Room: c.Room}); err != nil {
+			ClientNotef(time.Now(), "failed to leave room %q: %v", c.Room, err)
+			return true
+		}
+		ClientNotef(time.Now(), "left #%s", c.Room)
+		c.Room = defaultRoom
+		ClientNotef(time.Now(), "now talking in #%s", c.Room)
+	case "/rooms":
+		res, err := c.ChatClient.ListRooms(ctx, &chat.ListRoomsRequest{Token: c.token()})
+		if err != nil {
+			ClientNotef(time.Now(), "failed to list rooms: %v", err)
+			return true
+		}
+		for _, r := range res.Rooms {
+			ClientNotef(time.Now(), "#%s (%d): %s", r.Name, len(r.Members), strings.Join(r.Members, ", "))
+			if r.Topic != "" {
+				ClientNotef(time.Now(), "  topic: %s", r.Topic)
+			}
+		}
+	case "/history":
+		limit, _ := strconv.Atoi(arg)
+		res, err := c.ChatClient.History(ctx, &chat.HistoryRequest{Token: c.token(), Room: c.Room, Limit: int32(limit)})
+		if err != nil {
+			ClientNotef(time.Now(), "failed to load history: %v", err)
+			return true
+		}
+		for _, msg := range res.Messages {
+			if evt, ok := msg.Event.(*chat.StreamResponse_ClientMessage); ok {
+				MessageLog(msg.Timestamp.AsTime().In(time.Local), roomName(evt.ClientMessage.Room, evt.ClientMessage.Name), evt.ClientMessage.Message)
+			}
+		}
+	case "/who":
+		res, err := c.ChatClient.ListUsers(ctx, &chat.ListUsersRequest{Token: c.token()})
+		if err != nil {
+			ClientNotef(time.Now(), "failed to list users: %v", err)
+			return true
+		}
+		for _, u := range res.Users {
+			state := strings.ToLower(u.Status.String())
+			if !u.Connected {
+				state += ", not connected"
+			}
+			ClientNotef(time.Now(), "%s (%s) logged in %s, last active %s", u.Name, state,
+				u.LoginTime.AsTime().In(time.Local).Format(time.Kitchen),
+				u.LastActive.AsTime().In(time.Local).Format(time.Kitchen))
+		}
+	case "/search":
+		if arg == "" {
+			if c.nextSearch == nil {
+				ClientNotef(time.Now(), "usage: /search [from:<name>] [in:<room>] [after:<YYYY-MM-DD>] [before:<YYYY-MM-DD>] <words, \"a phrase\" or prefix*>")
+				return true
+			}
+			c.search(ctx, c.nextSearch)
+			return true
+		}
+		req, err := searchRequest(arg)
+		if err != nil {
+			ClientNotef(time.Now(), "%v", err)
+			return true
+		}
+		c.search(ctx, req)
+	case "/msg":
+		to, text, _ := strings.Cut(arg, " ")
+		if to == "" || strings.TrimSpace(text) == "" {
+			ClientNotef(time.Now(), "usage: /msg <name> <text>")
+			return true
+		}
+		if err := client.Send(&chat.StreamRequest{Message: text, Recipient: to}); err != nil {
+			ClientNotef(time.Now(), "failed to send direct message: %v", err)
+		}
+	case "/secret":
+		to, text, _ := strings.Cut(arg, " ")
+		if to == "" || strings.TrimSpace(text) == "" {
+			ClientNotef(time.Now(), "usage: /secret <name> <text>")
+			return true
+		}
+		req, err := c.sealDirect(ctx, to, text)
+		if err != nil {
+			ClientNotef(time.Now(), "failed to encrypt direct message: %v", err)
+			return true
+		}
+		if err := client.Send(req); err != nil {
+			ClientNotef(time.Now(), "failed to send direct message: %v", err)
+		}
+	case "/edit":
+		id, text, _ := strings.Cut(arg, " ")
+		if id == "" || strings.TrimSpace(text) == "" {
+			ClientNotef(time.Now(), "usage: /edit <id> <text>")
+			return true
+		}
+		c.sendAction(client, &chat.StreamRequest{Action: &chat.StreamRequest_EditMessage{
+			EditMessage: &chat.StreamRequest_Edit{Id: id, Message: text},
+		}})
+	case "/delete":
+		if arg == "" {
+			ClientNotef(time.Now(), "usage: /delete <id>")
+			return true
+		}
+		c.sendAction(client, &chat.StreamRequest{Action: &chat.StreamRequest_DeleteMessage{
+			DeleteMessage: &chat.StreamRequest_Delete{Id: arg},
+		}})
+	case "/react":
+		id, emoji, _ := strings.Cut(arg, " ")
+		if id == "" || strings.TrimSpace(emoji) == "" {
+			ClientNotef(time.Now(), "usage: /react <id> <emoji>")
+			return true
+		}
+		c.sendAction(client, &chat.StreamRequest{Action: &chat.StreamRequest_React{
+			React: &chat.StreamRequest_Reaction{Id: id, Emoji: strings.TrimSpace(emoji)},
+		}})
+	case "/send":
+		if arg == "" {
+			ClientNotef(time.Now(), "usage: /send <path>")
+			return true
+		}
+		// 大きなファイルでも発言を続けられるよう、転送は別のゴルーチンで行う
+		go func(file, room string) {
+			ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+			defer cancel()
+			id, err := c.upload(ctx, file, room)
+			if err != nil {
+				ClientNotef(time.Now(), "failed to send %s: %v", file, err)
+				return
+			}
+			c.Log.Debug("uploaded attachment", "file", file, "id", id)
+		}(arg, c.Room)
+	case "/get":
+		id, dest, _ := strings.Cut(arg, " ")
+		if id == "" || strings.TrimSpace(dest) == "" {
+			ClientNotef(time.Now(), "usage: /get <id> <dest>")
+			return true
+		}
+		go func(id, dest string) {
+			ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+			defer cancel()
+			saved, err := c.download(ctx, id, dest)
+			if err != nil {
+				ClientNotef(time.Now(), "failed to get %s: %v", id, err)
+				return
+			}
+			ClientNotef(time.Now(), "saved %s to %s", id, saved)
+		}(id, strings.TrimSpace(dest))
+	default:
+		return false
+	}
+	return true
+}
 
-	if err != nil {
-		return "", err
+// 発言以外の操作をサーバへ送信するメソッド
+func (c *client) sendAction(client chat.Chat_StreamClient, req *chat.StreamRequest) {
+	req.Room = c.Room
+	if err := client.Send(req); err != nil {
+		ClientNotef(time.Now(), "failed to send request: %v", err)
 	}
+}
 
-	return res.Token, nil
+// モデレーションのイベントを表示用の文字列にする関数
+func moderationText(mod *chat.StreamResponse_Moderation) string {
+	switch mod.Action {
+	case chat.StreamResponse_Moderation_KICK:
+		return fmt.Sprintf("%s kicked %s", mod.Moderator, mod.Target)
+	case chat.StreamResponse_Moderation_BAN:
+		return fmt.Sprintf("%s banned %s%s", mod.Moderator, mod.Target, untilText(mod.Until))
+	case chat.StreamResponse_Moderation_UNBAN:
+		return fmt.Sprintf("%s lifted the ban on %s", mod.Moderator, mod.Target)
+	case chat.StreamResponse_Moderation_MUTE:
+		return fmt.Sprintf("%s muted %s%s", mod.Moderator, mod.Target, untilText(mod.Until))
+	case chat.StreamResponse_Moderation_UNMUTE:
+		return fmt.Sprintf("%s unmuted %s", mod.Moderator, mod.Target)
+	case chat.StreamResponse_Moderation_TOPIC:
+		return fmt.Sprintf("%s set the topic of #%s to %q", mod.Moderator, mod.Room, mod.Topic)
+	}
+	return fmt.Sprintf("%s: %s %s", mod.Moderator, mod.Action, mod.Target)
 }
 
-// クライアントのログアウト処理を担うメソッド
-func (c *client) logout(ctx context.Context) error {
-	if c.Shutdown {
-		DebugLogf("unable to logout (server sent shutdown signal)")
-		return nil
+// ルーム名を付加した表示用の発言者名を返す関数
+func roomName(room, name string) string {
+	if room == "" || room == defaultRoom {
+		return name
 	}
+	return "#" + room + " " + name
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+// クライアントのアカウント登録処理を担うメソッド
+func (c *client) register(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
-	_, err := c.ChatClient.Logout(ctx, &chat.LogoutRequest{Token: c.Token})
-	if s, ok := status.FromError(err); ok && s.Code() == codes.Unavailable {
-		DebugLogf("unable to logout (connection already closed)")
-		return nil
-	}
-
+	_, err := c.ChatClient.Register(ctx, &chat.RegisterRequest{
+		Name:     c.Name,
+		Password: c.Password,
+	})
 	return err
 }
+
+// 現在のトークンを返すメソッド
+func (c *client) token() string {
+	return c.sess.Token()
+}
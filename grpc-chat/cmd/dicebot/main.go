@@ -0,0 +1,138 @@
+// dicebot はchatbotパッケージを使ったボットの例、"!echo"と"!roll"に応答し、ログインしたユーザに挨拶する
+//
+//	go run ./cmd/dicebot -h localhost:6262 -p <password>
+//	go run ./cmd/dicebot -h localhost:6262 -p <password> -tls-ca certs/ca.pem -mtls -tls-cert certs/client.pem -tls-key certs/client-key.pem
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	mrand "math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"grpc-chat/chatbot"
+	"grpc-chat/session"
+	"grpc-chat/tlsconf"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// サイコロの数と面の数の上限、1回の発言が長くなりすぎないようにする
+const (
+	maxDice  = 20
+	maxSides = 1000
+)
+
+func main() {
+	host := flag.String("h", "0.0.0.0:6262", "the chat server's host")
+	password := flag.String("p", "", "the chat server's password")
+	name := flag.String("n", "dicebot", "the bot's username")
+	var tlsFlags tlsconf.Config
+	tlsFlags.RegisterFlags(flag.CommandLine)
+	maxReconnects := flag.Int("reconnect-max", session.DefaultMaxReconnects, "how many times in a row the bot tries to reconnect a dropped stream; 0 disables reconnecting")
+	debug := flag.Bool("v", false, "enable debug logging")
+	flag.Parse()
+
+	level := slog.LevelInfo
+	if *debug {
+		level = slog.LevelDebug
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	// TLSの設定が何も無いときは平文で通信する
+	creds, err := tlsFlags.ClientCredentials(*host)
+	if err != nil {
+		log.Error("invalid TLS flags", "err", err)
+		os.Exit(2)
+	}
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.Dial(*host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Error("failed to connect to server", "err", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	b := exampleBot(*name)
+	b.Password = *password
+	b.MaxReconnects = *maxReconnects
+	b.Log = log.With("name", *name)
+	if err := b.Run(ctx, conn); err != nil {
+		log.Error("exiting", "err", err)
+		os.Exit(1)
+	}
+}
+
+// "!echo"と"!roll"に応答し、ログインしたユーザに挨拶するボットを生成する関数
+func exampleBot(name string) *chatbot.Bot {
+	b := chatbot.New(name)
+
+	b.Command("echo", "<text...>", "repeat the text back", func(m *chatbot.Message, args chatbot.Args) error {
+		m.Reply(args.String("text"))
+		return nil
+	})
+	b.Command("roll", "[dice]", "roll dice written as NdM, e.g. 2d6 (default 1d6)", func(m *chatbot.Message, args chatbot.Args) error {
+		spec := args.String("dice")
+		if spec == "" {
+			spec = "1d6"
+		}
+		rolls, err := rollDice(spec)
+		if err != nil {
+			return err
+		}
+		m.Reply(fmt.Sprintf("%s rolled %s: %s", m.From, spec, diceText(rolls)))
+		return nil
+	})
+	b.OnLogin(func(name string) {
+		b.Say("", fmt.Sprintf("welcome, %s! say %shelp to see what I can do", name, b.Prefix))
+	})
+	return b
+}
+
+// "NdM"の書式でN個のM面のサイコロを振る関数
+func rollDice(spec string) ([]int, error) {
+	n, m, ok := strings.Cut(strings.ToLower(spec), "d")
+	if n == "" {
+		n = "1"
+	}
+	count, err1 := strconv.Atoi(n)
+	sides, err2 := strconv.Atoi(m)
+	switch {
+	case !ok || err1 != nil || err2 != nil:
+		return nil, fmt.Errorf("dice must be written as NdM, e.g. 2d6: %w", chatbot.ErrUsage)
+	case count < 1 || count > maxDice || sides < 1 || sides > maxSides:
+		return nil, fmt.Errorf("roll 1 to %d dice with 1 to %d sides", maxDice, maxSides)
+	}
+
+	rolls := make([]int, count)
+	for i := range rolls {
+		rolls[i] = mrand.Intn(sides) + 1
+	}
+	return rolls, nil
+}
+
+// 出た目とその合計を表示用の文字列にする関数
+func diceText(rolls []int) string {
+	if len(rolls) == 1 {
+		return strconv.Itoa(rolls[0])
+	}
+	parts := make([]string, len(rolls))
+	sum := 0
+	for i, r := range rolls {
+		parts[i] = strconv.Itoa(r)
+		sum += r
+	}
+	return fmt.Sprintf("%s = %d", strings.Join(parts, " + "), sum)
+}
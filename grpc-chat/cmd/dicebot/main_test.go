@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"grpc-chat/chatbot"
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ボットにイベントを渡し、積まれた返信の本文を返す関数
+func reply(t *testing.T, b *chatbot.Bot, res *chat.StreamResponse) string {
+	t.Helper()
+
+	res.Timestamp = timestamppb.Now()
+	b.HandleEvent(res)
+	select {
+	case req := <-b.Requests():
+		return req.Message
+	default:
+		t.Fatal("the bot did not reply")
+		return ""
+	}
+}
+
+// ルームへの発言のイベントを生成する関数
+func say(from, text string) *chat.StreamResponse {
+	return &chat.StreamResponse{Event: &chat.StreamResponse_ClientMessage{
+		ClientMessage: &chat.StreamResponse_Message{Name: from, Message: text},
+	}}
+}
+
+func TestExampleBot(t *testing.T) {
+	b := exampleBot("dicebot")
+
+	login := &chat.StreamResponse{Event: &chat.StreamResponse_ClientLogin{ClientLogin: &chat.StreamResponse_Login{Name: "alice"}}}
+	require.Equal(t, "welcome, alice! say !help to see what I can do", reply(t, b, login))
+	require.Equal(t, "hello   bot", reply(t, b, say("alice", "!echo hello   bot")))
+	require.Equal(t, "alice rolled 3d1: 1 + 1 + 1 = 3", reply(t, b, say("alice", "!roll 3d1")))
+	require.Equal(t, "usage: !roll [dice]", reply(t, b, say("alice", "!roll lots")))
+}
+
+func TestRollDice(t *testing.T) {
+	rolls, err := rollDice("d1")
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, rolls)
+	require.Equal(t, "1", diceText(rolls))
+
+	rolls, err = rollDice("4d6")
+	require.NoError(t, err)
+	require.Len(t, rolls, 4)
+	for _, r := range rolls {
+		require.True(t, r >= 1 && r <= 6, r)
+	}
+
+	for _, spec := range []string{"6", "xd6", "0d6", "2d0", "100d6"} {
+		_, err := rollDice(spec)
+		require.Error(t, err, spec)
+	}
+}
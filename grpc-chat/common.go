@@ -2,38 +2,87 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
-const timeFormat = "2024-03-19 19:29"
+// チャットの画面に表示する時刻の書式
+const timeFormat = "2006-01-02 15:04:05"
 
-// デバッグ用のログを出力する関数
-func DebugLogf(format string, args ...interface{}) {
-	log.Printf("[DEBUG] "+format+"\n", args...)
+// 診断用のログの出力レベル、-vのときはデバッグ用のログも出力する
+var logLevel = new(slog.LevelVar)
+
+// 診断用のログを出力するロガー、configureLoggingで書式と出力先を設定する
+// 各コンポーネントはcomponentやtoken、name、methodなどの属性を付けて使う
+var logger = slog.New(slog.NewTextHandler(stdLogWriter{}, logOptions(logLevel)))
+
+// logパッケージの現在の出力先に書き込むio.Writer、クライアントの端末UIがlog.SetOutputで出力先を変えたときもそれに従う
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	return log.Writer().Write(p)
+}
+
+// 書式(textかjson)と出力先を指定してロガーを生成する関数、wがnilのときはlogパッケージの出力先に書き込む
+func newLogger(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	if w == nil {
+		w = stdLogWriter{}
+	}
+	opts := logOptions(level)
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, errors.Errorf("unknown log format %q (want text or json)", format)
 }
 
-// サーバのログを出力する関数
-func ServerLogf2(currentTime time.Time, message string) {
-	log.Printf("%s, %s", currentTime.Format(time.RFC3339), message)
+// ハンドラの設定を返す関数、errors.WithMessageなどのエラーはスタックトレースを含めずにメッセージだけを出力する
+func logOptions(level slog.Leveler) *slog.HandlerOptions {
+	return &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if err, ok := a.Value.Any().(error); ok && a.Value.Kind() == slog.KindAny {
+				a.Value = slog.StringValue(err.Error())
+			}
+			return a
+		},
+	}
 }
 
-// 指定された時刻とフォーマット指定されたメッセージをログに出力する関数
-func ServerLogf(logTime time.Time, messageFormat string, args ...interface{}) {
-	// メッセージフォーマットに従ってメッセージを組み立て
-	message := fmt.Sprintf(messageFormat, args...)
-	// 時刻と組み立てたメッセージをログに出力
-	log.Printf("%s, %s", logTime.Format(time.RFC3339), message)
+// 全体のロガーの書式と出力先、レベルを設定する関数、サーバなどを生成する前に呼び出す
+func configureLogging(w io.Writer, format string, verbose bool) error {
+	l, err := newLogger(w, format, logLevel)
+	if err != nil {
+		return err
+	}
+	if verbose {
+		logLevel.Set(slog.LevelDebug)
+	} else {
+		logLevel.Set(slog.LevelInfo)
+	}
+	logger = l
+	return nil
 }
 
-// クライアントのログを出力する関数
-func ClientLogf(ts time.Time, format string, args ...interface{}) {
-	log.Printf("[%s] <<Client>>: "+format, append([]interface{}{ts.Format(timeFormat)}, args...)...)
+// クライアントのお知らせを画面に表示する関数
+func ClientNotef(ts time.Time, format string, args ...interface{}) {
+	MessageLog(ts, "<<Client>>", fmt.Sprintf(format, args...))
+}
+
+// サーバから届いたイベントを画面に表示する関数
+func ServerNotef(ts time.Time, format string, args ...interface{}) {
+	MessageLog(ts, "<<Server>>", fmt.Sprintf(format, args...))
 }
 
 // Golangの標準パッケージを使ってOSからのシグナル(SIGINTやSIGTERM)を監視し、それらのシグナルを受信するとコンテキストをキャンセルする機能を提供
@@ -44,10 +93,10 @@ func SignalContext(ctx context.Context) context.Context {
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM) // SIGINT（通常はCtrl+Cによる中断）とSIGTERM（プロセスを終了させるためのシグナル）を受信した場合、それらのシグナルをsigsチャネルに送信するように設定(同一ファイルl:49へ)、これらは直接ctxには関連付けられていない、syscall.SIGINT, syscall.SIGTERMはどちらもOSシグナル
 
 	go func() {
-		DebugLogf("listening for shutdown signal")
+		logger.Debug("listening for shutdown signal")
 		// sigsがOSシグナルを受信するのを非同期で待機
 		<-sigs
-		DebugLogf("shutdown signal received")
+		logger.Debug("shutdown signal received")
 		signal.Stop(sigs) // signal.Notifyによるsigsチャネルへのシグナル送信を停止、これ以上のシグナルがチャネルに送信されないようになる
 		close(sigs)
 		// 各コンテキストをキャンセルし、適切なクリーンアップ・終了処理をする
@@ -57,6 +106,25 @@ func SignalContext(ctx context.Context) context.Context {
 	return ctx //	直接的には関連付けられていないが、根本を辿るとcancel変数の最初の段階でctx, cancel := context.WithCancel(ctx)なるctxにキャンセル処理の信号が送られ、それをctxを返り値にしてどこかに渡すことで全体に通知することを意図している(？)
 }
 
+// ファイルを書き換える関数、書き込み途中で終了しても壊れないよう同じディレクトリの一時ファイルから置き換える
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// チャットの画面に1行を表示する関数、診断用のログとは異なりレベルや書式の設定に関係なく表示する
 func MessageLog(ts time.Time, name, msg string) {
 	log.Printf("[%s] %s: %s", ts.Format(timeFormat), name, msg)
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// 複数のゴルーチンから書き込めるbytes.Buffer
+type syncBuffer struct {
+	buf bytes.Buffer
+	mtx sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := newLogger(&buf, "json", slog.LevelInfo)
+	require.NoError(t, err)
+
+	l = l.With("component", "server")
+	l.Debug("hidden")
+	l.Info("logged in", "token", "abc", "name", "alice")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), buf.String())
+	require.Equal(t, "INFO", entry["level"])
+	require.Equal(t, "logged in", entry["msg"])
+	require.Equal(t, "server", entry["component"])
+	require.Equal(t, "alice", entry["name"])
+
+	buf.Reset()
+	l, err = newLogger(&buf, "text", slog.LevelDebug)
+	require.NoError(t, err)
+	l.Debug("shown", "method", "/chat.Chat/Login")
+	require.Contains(t, buf.String(), "level=DEBUG msg=shown method=/chat.Chat/Login")
+
+	// エラーはスタックトレースを含めずにメッセージだけを出力する
+	buf.Reset()
+	l.Error("failed", "err", errors.WithMessage(errors.New("disk full"), "unable to save"))
+	require.Contains(t, buf.String(), `err="unable to save: disk full"`)
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+
+	_, err = newLogger(&buf, "xml", slog.LevelInfo)
+	require.Error(t, err)
+}
+
+func TestTimeFormat(t *testing.T) {
+	ts := time.Date(2024, 3, 19, 19, 29, 5, 0, time.UTC)
+	require.Equal(t, "2024-03-19 19:29:05", ts.Format(timeFormat))
+}
+
+func TestServerLogs(t *testing.T) {
+	var buf syncBuffer
+	ts := startServer(t, func(s *server) {
+		l, _ := newLogger(&buf, "json", slog.LevelDebug)
+		s.Log = l.With("component", "server")
+	})
+	alice := ts.startClient(t, "alice")
+	alice.say(t, "hello")
+	alice.expect(t, "alice's message", func(res *chat.StreamResponse) bool { return res.GetClientMessage().GetName() == "alice" })
+	ts.stop(t)
+
+	var login, call bool
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		require.Equal(t, "server", entry["component"])
+		switch {
+		case entry["msg"] == "logged in":
+			login = entry["name"] == "alice" && entry["token"] != ""
+		case entry["method"] == chat.Chat_Stream_FullMethodName:
+			call = entry["name"] == "alice" && entry["code"] != ""
+		}
+	}
+	require.True(t, login, "missing login entry")
+	require.True(t, call, "missing stream entry")
+	// パスワードはログに残さない
+	require.NotContains(t, buf.String(), testPassword)
+}
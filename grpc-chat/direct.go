@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"grpc-chat/e2e"
+	chat "grpc-chat/protos"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ダイレクトメッセージを宛先のユーザに配信するメソッド、宛先がオフラインのときは送信者にエラーイベントを返す
+// 他のレプリカに接続しているユーザはこのレプリカからは見えないので、プロセス外のブローカーを使うときは確認しない
+func (s *server) directMessage(tkn, name string, req *chat.StreamRequest) {
+	if _, local := s.Broker.(*localBroker); local && !s.isOnline(req.Recipient) {
+		s.Log.Debug("direct message to offline user", "token", tkn, "name", name, "recipient", req.Recipient)
+		s.sendError(tkn, fmt.Sprintf("%s is not online", req.Recipient))
+		return
+	}
+	// 暗号化したメッセージは中身を確認できないので、形式だけを確かめてそのまま中継する
+	if req.Sealed != nil {
+		if msg := s.checkSealed(req); msg != "" {
+			s.sendError(tkn, msg)
+			return
+		}
+	}
+
+	s.Broadcast <- &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_DirectMessage{
+			DirectMessage: &chat.StreamResponse_Direct{
+				From:    name,
+				To:      req.Recipient,
+				Message: req.Message,
+				Sealed:  req.Sealed,
+			},
+		},
+	}
+}
+
+// 暗号化したダイレクトメッセージの形式を確かめるメソッド、問題があるときは送信者への警告を返す
+// 宛先が公開している鍵と異なる鍵で暗号化したメッセージは、宛先が再ログインして鍵が変わった後の古い鍵によるものなので届けない
+// 宛先の鍵が分からないときは正しい宛先に暗号化されたかを確かめられないので届けない
+func (s *server) checkSealed(req *chat.StreamRequest) string {
+	sealed := req.Sealed
+	switch {
+	case req.Message != "":
+		return "an encrypted message cannot also carry plaintext"
+	case e2e.ValidPublicKey(sealed.SenderKey) != nil || e2e.ValidPublicKey(sealed.RecipientKey) != nil:
+		return "an encrypted message must include the sender's and the recipient's public keys"
+	}
+	key, ok := s.publicKey(req.Recipient)
+	switch {
+	case !ok:
+		return fmt.Sprintf("%s has not published a public key", req.Recipient)
+	case !bytes.Equal(key, sealed.RecipientKey):
+		return fmt.Sprintf("%s's public key has changed, fetch it again", req.Recipient)
+	}
+	return ""
+}
+
+// ユーザがログイン時に公開した公開鍵を返すメソッド
+func (s *server) GetPublicKey(_ context.Context, req *chat.GetPublicKeyRequest) (*chat.GetPublicKeyResponse, error) {
+	key, ok := s.publicKey(req.Name)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "%s has not published a public key", req.Name)
+	}
+	return &chat.GetPublicKeyResponse{Name: req.Name, PublicKey: key}, nil
+}
+
+// ユーザの公開鍵を返すメソッド、このレプリカにログインしていないときはブローカーに登録された鍵を探す
+// ログインしていないか鍵を公開していないときはfalseを返す
+func (s *server) publicKey(name string) ([]byte, bool) {
+	for _, tkn := range s.getTokens(name) {
+		if p := s.getPresence(tkn); len(p.PublicKey) > 0 {
+			return p.PublicKey, true
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	key, ok, err := s.Broker.PublicKey(ctx, name)
+	if err != nil {
+		s.Log.Warn("unable to look up public key", "name", name, "err", err)
+		return nil, false
+	}
+	return key, ok
+}
+
+// ユーザ名からトークンを引くメソッド、同名のユーザが複数ログインしている場合は全てのトークンを返す
+func (s *server) getTokens(name string) (tkns []string) {
+	s.namesMtx.RLock()
+	for tkn, n := range s.ClientNames {
+		if n == name {
+			tkns = append(tkns, tkn)
+		}
+	}
+	s.namesMtx.RUnlock()
+	return
+}
+
+// ユーザがストリームを開いていて、メッセージを受け取れる状態かを確認するメソッド
+func (s *server) isOnline(name string) bool {
+	s.streamsMtx.RLock()
+	defer s.streamsMtx.RUnlock()
+
+	for _, tkn := range s.getTokens(name) {
+		if _, ok := s.ClientStreams[tkn]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 特定のクライアントにのみエラーイベントを送信するメソッド
+func (s *server) sendError(tkn, msg string) {
+	s.sendTo(tkn, &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_ServerError{
+			ServerError: &chat.StreamResponse_Error{
+				Message: msg,
+			},
+		},
+	})
+}
+
+// ブロードキャストを経由せずに特定のクライアントのストリームにイベントを送信するメソッド
+func (s *server) sendTo(tkn string, res *chat.StreamResponse) {
+	s.streamsMtx.RLock()
+	defer s.streamsMtx.RUnlock()
+
+	if stream, ok := s.ClientStreams[tkn]; ok {
+		s.deliver(tkn, stream, res)
+	}
+}
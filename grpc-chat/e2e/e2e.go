@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ダイレクトメッセージのエンドツーエンド暗号化用ファイル
+// X25519で共有した秘密からHKDF-SHA256で鍵を導出し、XChaCha20-Poly1305で暗号化する
+
+// KeySize is the length of X25519 public and private keys
+const KeySize = curve25519.PointSize
+
+// 鍵の導出に使う文脈、形式を変えるときはバージョンを上げて古い鍵と混ざらないようにする
+const hkdfInfo = "grpc-chat e2e v1"
+
+// different types of error returned by Seal and Open
+var (
+	ErrInvalidKey = errors.New("invalid public key")
+	ErrDecrypt    = errors.New("message authentication failed")
+)
+
+// KeyPair is an X25519 key pair used to encrypt direct messages
+type KeyPair struct {
+	Public  []byte
+	private []byte
+}
+
+// ランダムな鍵ペアを生成する
+func GenerateKey() (*KeyPair, error) {
+	priv := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, priv); err != nil {
+		return nil, err
+	}
+	pub, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{Public: pub, private: priv}, nil
+}
+
+// 公開鍵として使える長さかを確認する
+func ValidPublicKey(key []byte) error {
+	if len(key) != KeySize {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// fromからtoへのメッセージをpeerの公開鍵との共有鍵で暗号化し、nonceと暗号文を返す
+func (k *KeyPair) Seal(peer []byte, from, to string, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	aead, err := k.aead(peer)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, associatedData(from, to)), nil
+}
+
+// Sealで暗号化したメッセージを復号する、鍵や送信者・宛先が異なるときや改ざんされているときはErrDecryptを返す
+func (k *KeyPair) Open(peer []byte, from, to string, nonce, ciphertext []byte) ([]byte, error) {
+	aead, err := k.aead(peer)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != chacha20poly1305.NonceSizeX {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData(from, to))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// peerとの共有鍵からAEADを生成する、どちらの側から導出しても同じ鍵になるよう2つの公開鍵を順に並べて文脈に含める
+func (k *KeyPair) aead(peer []byte) (cipher.AEAD, error) {
+	if err := ValidPublicKey(peer); err != nil {
+		return nil, err
+	}
+	// 低位数の点など共有した秘密が0になる公開鍵はX25519がエラーにする
+	shared, err := curve25519.X25519(k.private, peer)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	lo, hi := k.Public, peer
+	if bytes.Compare(lo, hi) > 0 {
+		lo, hi = hi, lo
+	}
+	info := append(append([]byte(hkdfInfo), lo...), hi...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, info), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// 送信者と宛先のユーザ名を認証付きデータにする、サーバが暗号文を別のユーザ間のメッセージとして中継しても復号できない
+func associatedData(from, to string) []byte {
+	return []byte(from + "\x00" + to)
+}
@@ -0,0 +1,45 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	alice, err := GenerateKey()
+	require.NoError(t, err)
+	bob, err := GenerateKey()
+	require.NoError(t, err)
+	require.Len(t, alice.Public, KeySize)
+
+	nonce, ct, err := alice.Seal(bob.Public, "alice", "bob", []byte("hello"))
+	require.NoError(t, err)
+	require.NotContains(t, string(ct), "hello")
+
+	// 受信者も送信者も相手の公開鍵から同じ鍵を導出できる
+	got, err := bob.Open(alice.Public, "alice", "bob", nonce, ct)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(got))
+	got, err = alice.Open(bob.Public, "alice", "bob", nonce, ct)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(got))
+
+	// 送信者や宛先の付け替え、改ざん、別の鍵では復号できない
+	_, err = bob.Open(alice.Public, "mallory", "bob", nonce, ct)
+	require.Equal(t, ErrDecrypt, err)
+	tampered := append([]byte(nil), ct...)
+	tampered[0] ^= 1
+	_, err = bob.Open(alice.Public, "alice", "bob", nonce, tampered)
+	require.Equal(t, ErrDecrypt, err)
+	mallory, err := GenerateKey()
+	require.NoError(t, err)
+	_, err = mallory.Open(alice.Public, "alice", "bob", nonce, ct)
+	require.Equal(t, ErrDecrypt, err)
+
+	// 長さの違う鍵や共有した秘密が0になる鍵は使えない
+	_, _, err = alice.Seal(bob.Public[:16], "alice", "bob", nil)
+	require.Equal(t, ErrInvalidKey, err)
+	_, _, err = alice.Seal(make([]byte, KeySize), "alice", "bob", nil)
+	require.Equal(t, ErrInvalidKey, err)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"grpc-chat/e2e"
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// ダイレクトメッセージを表示するメソッド、暗号化されたものは復号と検証に成功したときだけ表示する
+func (c *client) directMessage(ctx context.Context, ts time.Time, dm *chat.StreamResponse_Direct) {
+	if dm.Sealed == nil {
+		MessageLog(ts, dm.From+" -> "+dm.To, dm.Message)
+		return
+	}
+	text, err := c.openDirect(ctx, dm)
+	if err != nil {
+		ClientNotef(ts, "unable to decrypt the direct message from %s to %s: %v", dm.From, dm.To, err)
+		return
+	}
+	MessageLog(ts, dm.From+" -> "+dm.To+" (encrypted)", text)
+}
+
+// toの公開鍵で暗号化したダイレクトメッセージのリクエストを生成するメソッド
+func (c *client) sealDirect(ctx context.Context, to, text string) (*chat.StreamRequest, error) {
+	peer, err := c.peerKey(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	nonce, ciphertext, err := c.Keys.Seal(peer, c.Name, to, []byte(text))
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to encrypt message")
+	}
+	return &chat.StreamRequest{
+		Recipient: to,
+		Sealed: &chat.Sealed{
+			SenderKey:    c.Keys.Public,
+			RecipientKey: peer,
+			Nonce:        nonce,
+			Ciphertext:   ciphertext,
+		},
+	}, nil
+}
+
+// 暗号化されたダイレクトメッセージを復号するメソッド、相手の鍵が公開されているものと一致し、改ざんされていないことを確かめる
+// 自分が送ったメッセージは宛先の鍵、受け取ったメッセージは送信者の鍵との共有鍵で復号する
+func (c *client) openDirect(ctx context.Context, dm *chat.StreamResponse_Direct) (string, error) {
+	sealed := dm.Sealed
+	mine, peer, peerName := sealed.RecipientKey, sealed.SenderKey, dm.From
+	if dm.From == c.Name {
+		mine, peer, peerName = sealed.SenderKey, sealed.RecipientKey, dm.To
+	}
+	if !bytes.Equal(mine, c.Keys.Public) {
+		return "", errors.New("the message was encrypted for a different key (from an earlier session?)")
+	}
+	if err := c.verifyPeer(ctx, peerName, peer); err != nil {
+		return "", err
+	}
+
+	plaintext, err := c.Keys.Open(peer, dm.From, dm.To, sealed.Nonce, sealed.Ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// 相手の公開鍵を返すメソッド、まだ知らないときはサーバから取得して覚えておく
+func (c *client) peerKey(ctx context.Context, name string) ([]byte, error) {
+	c.keysMtx.Lock()
+	key, ok := c.peerKeys[name]
+	c.keysMtx.Unlock()
+	if ok {
+		return key, nil
+	}
+	return c.fetchKey(ctx, name)
+}
+
+// メッセージに含まれていた相手の鍵が、覚えている鍵かサーバが公開している鍵と一致するか確かめるメソッド
+func (c *client) verifyPeer(ctx context.Context, name string, key []byte) error {
+	c.keysMtx.Lock()
+	known, ok := c.peerKeys[name]
+	c.keysMtx.Unlock()
+	if ok && bytes.Equal(known, key) {
+		return nil
+	}
+
+	// 相手が再ログインして鍵が変わったときは、公開されている新しい鍵と照合する
+	published, err := c.fetchKey(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(published, key) {
+		return errors.Errorf("the key does not match the one %s has published", name)
+	}
+	return nil
+}
+
+// 相手の公開鍵をサーバから取得して覚えるメソッド、前に覚えていた鍵から変わったときは指紋を表示して知らせる
+func (c *client) fetchKey(ctx context.Context, name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	res, err := c.ChatClient.GetPublicKey(ctx, &chat.GetPublicKeyRequest{Token: c.token(), Name: name})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to get %s's public key", name)
+	}
+	if err := e2e.ValidPublicKey(res.PublicKey); err != nil {
+		return nil, errors.WithMessagef(err, "%s's public key", name)
+	}
+
+	c.keysMtx.Lock()
+	known, ok := c.peerKeys[name]
+	c.peerKeys[name] = res.PublicKey
+	c.keysMtx.Unlock()
+
+	switch {
+	case !ok:
+		ClientNotef(time.Now(), "%s's key fingerprint is %s", name, fingerprint(res.PublicKey))
+	case !bytes.Equal(known, res.PublicKey):
+		ClientNotef(time.Now(), "%s's key has changed, the new fingerprint is %s", name, fingerprint(res.PublicKey))
+	}
+	return res.PublicKey, nil
+}
+
+// 公開鍵の指紋を返す関数、相手と別の手段で見比べて鍵がすり替えられていないか確かめるのに使う
+func fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	h := hex.EncodeToString(sum[:8])
+	return fmt.Sprintf("%s %s %s %s", h[0:4], h[4:8], h[8:12], h[12:16])
+}
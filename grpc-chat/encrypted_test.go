@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"grpc-chat/e2e"
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEncryptedDirectMessage(t *testing.T) {
+	ts := startServer(t)
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	alice.say(t, "/secret bob meet at noon")
+	sealed := func(res *chat.StreamResponse) bool { return res.GetDirectMessage().GetSealed() != nil }
+	res := bob.expect(t, "the encrypted message", sealed)
+
+	// サーバが中継するのは暗号文だけ
+	dm := res.GetDirectMessage()
+	require.Empty(t, dm.Message)
+	require.NotContains(t, string(dm.Sealed.Ciphertext), "noon")
+	require.Equal(t, alice.Keys.Public, dm.Sealed.SenderKey)
+
+	text, err := bob.openDirect(ctx, dm)
+	require.NoError(t, err)
+	require.Equal(t, "meet at noon", text)
+	text, err = alice.openDirect(ctx, alice.expect(t, "her own message", sealed).GetDirectMessage())
+	require.NoError(t, err)
+	require.Equal(t, "meet at noon", text)
+
+	// 他のユーザ宛てのメッセージとして付け替えたものは復号できない
+	forged := proto.Clone(dm).(*chat.StreamResponse_Direct)
+	forged.To = "carol"
+	_, err = bob.openDirect(ctx, forged)
+	require.Error(t, err)
+
+	// 宛先が今公開している鍵と異なる鍵で暗号化したメッセージは届けない
+	carol := ts.startClient(t, "carol")
+	stale, err := e2e.GenerateKey()
+	require.NoError(t, err)
+	carol.keysMtx.Lock()
+	carol.peerKeys["alice"] = stale.Public
+	carol.keysMtx.Unlock()
+	carol.say(t, "/secret alice hello")
+	res = carol.expect(t, "a key mismatch error", serverError)
+	require.Contains(t, res.GetServerError().Message, "public key has changed")
+
+	// 鍵を公開していない宛先には、どの鍵で暗号化したメッセージも届けない
+	c := chat.NewChatClient(ts.conn(t))
+	login, err := c.Login(ctx, &chat.LoginRequest{Name: "dave", Password: testPassword})
+	require.NoError(t, err)
+	_, err = c.Stream(outgoingContext(ctx, login.Token, 0))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return ts.isOnline("dave") }, testTimeout, 10*time.Millisecond)
+	carol.keysMtx.Lock()
+	carol.peerKeys["dave"] = stale.Public
+	carol.keysMtx.Unlock()
+	carol.say(t, "/secret dave hello")
+	res = carol.expect(t, "an unknown key error", serverError)
+	require.Contains(t, res.GetServerError().Message, "has not published a public key")
+}
+
+func TestGetPublicKey(t *testing.T) {
+	ts := startServer(t)
+	alice := ts.startClient(t, "alice")
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := chat.NewChatClient(ts.conn(t))
+
+	res, err := c.GetPublicKey(outgoingContext(ctx, alice.token(), 0), &chat.GetPublicKeyRequest{Name: "alice"})
+	require.NoError(t, err)
+	require.Equal(t, alice.Keys.Public, res.PublicKey)
+
+	_, err = c.GetPublicKey(outgoingContext(ctx, alice.token(), 0), &chat.GetPublicKeyRequest{Name: "bob"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.GetPublicKey(ctx, &chat.GetPublicKeyRequest{Name: "alice"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 公開鍵は省略できるが、長さの違う鍵は受け付けない
+	_, err = c.Login(ctx, &chat.LoginRequest{Name: "bob", Password: testPassword, PublicKey: []byte(strings.Repeat("k", 16))})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.Login(ctx, &chat.LoginRequest{Name: "bob", Password: testPassword})
+	require.NoError(t, err)
+	_, err = c.GetPublicKey(outgoingContext(ctx, alice.token(), 0), &chat.GetPublicKeyRequest{Name: "bob"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
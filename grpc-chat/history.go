@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 保存されている過去のメッセージを返すメソッド、ストリームと同じく参加していないルームの発言は返さない
+// ルームを指定しないときは、ルーム宛てでないイベントと参加している全てのルームのイベントを返す
+func (s *server) History(ctx context.Context, req *chat.HistoryRequest) (*chat.HistoryResponse, error) {
+	payload := sessionFrom(ctx)
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	var since time.Time
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+
+	rooms := roomFilter{joined: s.roomsOf(payload.ID)}
+	if req.Room != "" {
+		rooms.room = normalizeRoom(req.Room)
+		if _, ok := rooms.joined[rooms.room]; !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "not a member of room %q", rooms.room)
+		}
+	}
+
+	msgs, err := s.Store.History(rooms, since, limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to load history")
+	}
+
+	return &chat.HistoryResponse{Messages: msgs}, nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+	"grpc-chat/token"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// トークンの検証を行わないメソッド、ログイン前に呼び出されるもの
+var authExempt = map[string]bool{
+	chat.Chat_Register_FullMethodName: true,
+	chat.Chat_Login_FullMethodName:    true,
+}
+
+// トークンの検証を行わないサービス、ロードバランサのヘルスチェックやgrpcurlなどの調査用のクライアントが使う
+var publicServices = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// メソッドがトークンの検証を行わないものかを判定する関数
+func isAuthExempt(method string) bool {
+	if authExempt[method] {
+		return true
+	}
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 認証済みのセッションをコンテキストに格納するためのキー
+type sessionKey struct{}
+
+// 認証済みのセッションを格納したコンテキストを返す関数
+func withSession(ctx context.Context, payload *token.Payload) context.Context {
+	// 外側のログのインターセプタにもセッションを知らせる
+	if cs, ok := ctx.Value(callSessionKey{}).(*callSession); ok {
+		cs.token, cs.name = payload.ID, payload.Username
+	}
+	return context.WithValue(ctx, sessionKey{}, payload)
+}
+
+// 認証インターセプタが格納したセッションを取り出す関数、認証対象外のメソッドではnilを返す
+func sessionFrom(ctx context.Context) *token.Payload {
+	payload, _ := ctx.Value(sessionKey{}).(*token.Payload)
+	return payload
+}
+
+// リクエスト本文にトークンを持つメッセージ、メタデータにトークンが無いときはこちらを使う
+type tokenRequest interface {
+	GetToken() string
+}
+
+// サーバに登録するunaryインターセプタの一覧、外側から順にパニックの回復、ログ、メトリクス、認証
+func (s *server) unaryInterceptors() []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		s.recoveryUnaryInterceptor,
+		s.loggingUnaryInterceptor,
+		s.Metrics.unaryInterceptor,
+		s.authUnaryInterceptor,
+	}
+}
+
+// サーバに登録するstreamインターセプタの一覧、並びはunaryと同じ
+func (s *server) streamInterceptors() []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		s.recoveryStreamInterceptor,
+		s.loggingStreamInterceptor,
+		s.Metrics.streamInterceptor,
+		s.authStreamInterceptor,
+	}
+}
+
+// トークンを検証し、セッションをコンテキストに格納してからハンドラを呼び出すインターセプタ
+func (s *server) authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if isAuthExempt(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	tkn, ok := extractToken(ctx)
+	if !ok {
+		if r, isTokenRequest := req.(tokenRequest); isTokenRequest && r.GetToken() != "" {
+			tkn, ok = r.GetToken(), true
+		}
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	payload, err := s.authenticate(tkn)
+	if err != nil {
+		return nil, err
+	}
+	return handler(withSession(ctx, payload), req)
+}
+
+// ストリームを開く前にメタデータのトークンを検証するインターセプタ
+func (s *server) authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if isAuthExempt(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	tkn, ok := extractToken(ss.Context())
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing token header")
+	}
+
+	payload, err := s.authenticate(tkn)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &sessionStream{ServerStream: ss, ctx: withSession(ss.Context(), payload)})
+}
+
+// コンテキストだけを差し替えたgrpc.ServerStream
+type sessionStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss *sessionStream) Context() context.Context { return ss.ctx }
+
+// 呼び出し1回分のログに付けるセッションの情報、ログのインターセプタより内側で認証したときに書き込まれる
+type callSession struct {
+	token, name string
+}
+
+// callSessionをコンテキストに格納するためのキー
+type callSessionKey struct{}
+
+// メソッド名、処理時間、ステータスコード、呼び出したセッションをログに出力するメソッド
+func (s *server) logCall(method string, cs *callSession, err error, d time.Duration) {
+	attrs := []any{"method", method, "code", status.Code(err).String(), "duration", d}
+	if cs.token != "" {
+		attrs = append(attrs, "token", cs.token, "name", cs.name)
+	}
+	s.Log.Info("rpc finished", attrs...)
+}
+
+// メソッド名、処理時間、ステータスコードをログに出力するインターセプタ
+func (s *server) loggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	cs := new(callSession)
+	res, err := handler(context.WithValue(ctx, callSessionKey{}, cs), req)
+	s.logCall(info.FullMethod, cs, err, time.Since(start))
+	return res, err
+}
+
+// ストリームが閉じたときにメソッド名、接続時間、ステータスコードをログに出力するインターセプタ
+func (s *server) loggingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	cs := new(callSession)
+	err := handler(srv, &sessionStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), callSessionKey{}, cs)})
+	s.logCall(info.FullMethod, cs, err, time.Since(start))
+	return err
+}
+
+// ハンドラ内のパニックを回復してcodes.Internalのエラーに変換するインターセプタ
+func (s *server) recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.Log.Error("panic in handler", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// ストリームのハンドラ内のパニックを回復してcodes.Internalのエラーに変換するインターセプタ
+func (s *server) recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.Log.Error("panic in handler", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// メソッドごとの呼び出し回数、ステータスコードごとの件数、合計処理時間を集計する
+type rpcMetrics struct {
+	methods map[string]*methodMetrics
+	mtx     sync.Mutex
+}
+
+// 1つのメソッドの集計値
+type methodMetrics struct {
+	Calls    int64
+	Codes    map[codes.Code]int64
+	Duration time.Duration
+}
+
+func newRPCMetrics() *rpcMetrics {
+	return &rpcMetrics{methods: make(map[string]*methodMetrics)}
+}
+
+// 呼び出し1回分の結果を集計に加えるメソッド
+func (m *rpcMetrics) observe(method string, err error, d time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	mm, ok := m.methods[method]
+	if !ok {
+		mm = &methodMetrics{Codes: make(map[codes.Code]int64)}
+		m.methods[method] = mm
+	}
+	mm.Calls++
+	mm.Codes[status.Code(err)]++
+	mm.Duration += d
+}
+
+// 集計値のコピーをメソッド名の順に返すメソッド
+func (m *rpcMetrics) Snapshot() (methods []string, snapshot map[string]methodMetrics) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	snapshot = make(map[string]methodMetrics, len(m.methods))
+	for method, mm := range m.methods {
+		c := *mm
+		c.Codes = make(map[codes.Code]int64, len(mm.Codes))
+		for code, n := range mm.Codes {
+			c.Codes[code] = n
+		}
+		snapshot[method] = c
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return
+}
+
+func (m *rpcMetrics) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	m.observe(info.FullMethod, err, time.Since(start))
+	return res, err
+}
+
+func (m *rpcMetrics) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.observe(info.FullMethod, err, time.Since(start))
+	return err
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// brokenにしてからの履歴の読み込みでパニックを起こす保存先
+type panicStore struct {
+	MessageStore
+	broken atomic.Bool
+}
+
+func (p *panicStore) History(rooms roomFilter, since time.Time, limit int) ([]*chat.StreamResponse, error) {
+	if p.broken.Load() {
+		panic("history is broken")
+	}
+	return p.MessageStore.History(rooms, since, limit)
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	var buf syncBuffer
+	store := new(panicStore)
+	ts := startServer(t, func(s *server) {
+		store.MessageStore = s.Store
+		s.Store = store
+		l, _ := newLogger(&buf, "json", slog.LevelInfo)
+		s.Log = l
+	})
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	res, err := cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+	ctx = outgoingContext(ctx, res.Token, 0)
+
+	// ハンドラのパニックはcodes.Internalとしてクライアントに返る
+	store.broken.Store(true)
+	_, err = cc.History(ctx, new(chat.HistoryRequest))
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Contains(t, buf.String(), "panic in handler")
+
+	// パニックの後もサーバは他の呼び出しに応答し続ける
+	store.broken.Store(false)
+	_, err = cc.History(ctx, new(chat.HistoryRequest))
+	require.NoError(t, err)
+	_, err = cc.ListRooms(ctx, new(chat.ListRoomsRequest))
+	require.NoError(t, err)
+	_, err = cc.Login(ctx, &chat.LoginRequest{Name: "bob", Password: testPassword})
+	require.NoError(t, err)
+
+	// ストリームのハンドラのパニックも同じく変換する
+	err = ts.recoveryStreamInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: chat.Chat_Stream_FullMethodName},
+		func(interface{}, grpc.ServerStream) error { panic("stream is broken") })
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	var buf syncBuffer
+	ts := startServer(t, func(s *server) {
+		l, _ := newLogger(&buf, "json", slog.LevelInfo)
+		s.Log = l
+	})
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	res, err := cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+	_, err = cc.History(outgoingContext(ctx, res.Token, 0), &chat.HistoryRequest{Room: "elsewhere"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	calls := make(map[string]map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		if entry["msg"] == "rpc finished" {
+			calls[entry["method"].(string)] = entry
+		}
+	}
+
+	// メソッド名、ステータスコード、処理時間を記録し、認証したセッションがあればそれも記録する
+	login := calls[chat.Chat_Login_FullMethodName]
+	require.NotNil(t, login, "missing login entry")
+	require.Equal(t, codes.OK.String(), login["code"])
+	require.Contains(t, login, "duration")
+	require.NotContains(t, login, "token")
+
+	history := calls[chat.Chat_History_FullMethodName]
+	require.NotNil(t, history, "missing history entry")
+	require.Equal(t, codes.PermissionDenied.String(), history["code"])
+	require.Greater(t, history["duration"], 0.0)
+	require.Equal(t, "alice", history["name"])
+	require.NotEmpty(t, history["token"])
+}
@@ -3,29 +3,110 @@ package main
 import (
 	"crypto/rand"
 	"flag"
+	"io"
 	"log"
 	"math/big"
 	"os"
+	"strings"
 	"time"
 
+	"grpc-chat/session"
+	"grpc-chat/tlsconf"
+	"grpc-chat/token"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
 var (
 	serverMode bool
 	debugMode  bool
+	logFormat  string
+	logFile    string
 	host       string
 	password   string
 	username   string
+
+	historyFile string
+	historySize int
+	historyMax  int64
+	replaySize  int
+
+	tokenSecret string
+	tokenTTL    time.Duration
+
+	usersFile string
+	register  bool
+
+	tlsFlags tlsconf.Config
+
+	backpressure string
+	blockTimeout time.Duration
+
+	maxReconnects int
+
+	brokerURL string
+
+	moderators string
+	bansFile   string
+
+	blobDir     string
+	maxUpload   int64
+	uploadQuota int64
+
+	tuiMode bool
+
+	httpAddr string
+
+	metricsAddr string
+
+	shutdownGrace time.Duration
+	sessionsFile  string
+
+	rateMessages    float64
+	rateBurst       int
+	maxMessageBytes int
+	loginRate       int
+	trustedProxies  string
 )
 
 func init() {
 	flag.BoolVar(&serverMode, "s", false, "run as the server")
 	flag.BoolVar(&debugMode, "v", false, "enable debug logging")
+	flag.StringVar(&logFormat, "log-format", "text", "the format of diagnostic logs: text or json")
+	flag.StringVar(&logFile, "log-file", "", "append diagnostic logs to this file instead of standard error (or the -tui message pane)")
 	flag.StringVar(&host, "h", "0.0.0.0:6262", "the chat server's host")
 	flag.StringVar(&password, "p", "", "the chat server's password")
 	flag.StringVar(&username, "n", "", "the username for the client")
-	flag.Parse()
+	flag.StringVar(&historyFile, "history-file", "", "append message history to this file instead of keeping it in memory")
+	flag.IntVar(&historySize, "history-size", defaultHistorySize, "the number of messages kept in the in-memory history, or cached from the end of -history-file")
+	flag.Int64Var(&historyMax, "history-max-bytes", defaultHistoryMaxBytes, "rotate -history-file to <file>.1 when it grows past this many bytes, dropping the previous <file>.1; 0 keeps the whole history")
+	flag.IntVar(&replaySize, "replay", defaultReplaySize, "the number of recent messages replayed to a newly opened stream")
+	flag.StringVar(&tokenSecret, "token-secret", "", "the key (at least 32 characters) used to sign session tokens; random if empty, so tokens do not survive a restart")
+	flag.DurationVar(&tokenTTL, "token-ttl", defaultTokenTTL, "how long a session token is valid before it must be refreshed")
+	flag.StringVar(&usersFile, "users", "", "a JSON file of user accounts; when set, users log in with their own password instead of -p")
+	flag.BoolVar(&register, "register", false, "register the client's name and password before logging in")
+	tlsFlags.RegisterFlags(flag.CommandLine)
+	flag.StringVar(&backpressure, "backpressure", string(dropNewest), "what to do when a client's stream is full: drop-newest, drop-oldest, disconnect or block")
+	flag.DurationVar(&blockTimeout, "block-timeout", 100*time.Millisecond, "how long the block backpressure policy waits before dropping a message")
+	flag.StringVar(&brokerURL, "broker", "", "share messages between server replicas through this broker, e.g. redis://localhost:6379/0; empty keeps them in this process")
+	flag.StringVar(&moderators, "moderators", "", "comma separated names allowed to /kick, /ban, /mute and /topic; these names cannot be registered, so add their accounts to -users before listing them")
+	flag.StringVar(&bansFile, "bans", "", "a JSON file the ban list is saved to so bans survive a restart")
+	flag.StringVar(&blobDir, "blob-dir", "", "store file attachments in this directory; attachments are disabled when empty")
+	flag.Int64Var(&maxUpload, "max-upload", defaultMaxUpload, "the largest file in bytes a client can attach")
+	flag.Int64Var(&uploadQuota, "upload-quota", defaultUploadBytesPerHour, "how many bytes each user may upload per hour; 0 disables the limit")
+	flag.StringVar(&httpAddr, "http", "", "run an HTTP/WebSocket bridge for browser clients on this address, relaying to the server at -h")
+	flag.DurationVar(&shutdownGrace, "shutdown-grace", defaultShutdownGrace, "how long the server waits on shutdown for clients to receive queued messages before closing their streams")
+	flag.StringVar(&sessionsFile, "sessions", "", "a JSON file the active sessions are saved to on shutdown, so clients can resume them after a restart (use with -token-secret)")
+	flag.StringVar(&metricsAddr, "metrics", "", "serve Prometheus metrics at /metrics on this address, e.g. :9090; disabled when empty")
+	flag.Float64Var(&rateMessages, "rate-messages", defaultMessageRate, "how many messages per second each session may send on average; 0 disables the limit")
+	flag.IntVar(&rateBurst, "rate-burst", defaultMessageBurst, "how many messages a session may send at once before -rate-messages applies")
+	flag.IntVar(&maxMessageBytes, "max-message-bytes", defaultMaxMessageBytes, "the largest message in bytes a client can send; 0 disables the limit")
+	flag.IntVar(&loginRate, "login-rate", defaultLoginsPerMinute, "how many login attempts per minute are allowed from one IP address; 0 disables the limit")
+	flag.StringVar(&trustedProxies, "trusted-proxies", "", "comma separated IPs or CIDRs of -http bridges; logins relayed by them are limited by the browser's IP instead of the bridge's")
+	flag.BoolVar(&tuiMode, "tui", false, "run the client as a full-screen terminal UI; typing indicators are only sent from the TUI, as the line-based client cannot see keystrokes before Enter")
+	flag.IntVar(&maxReconnects, "reconnect-max", session.DefaultMaxReconnects, "how many times in a row the client tries to reconnect a dropped stream; 0 disables reconnecting")
 }
 
 // 【確認】
@@ -42,21 +123,187 @@ func init() {
 	log.SetFlags(0)
 }
 func main() {
+	// テストのバイナリが独自のフラグを解析できるよう、フラグの解析はinitではなくここで行う
+	flag.Parse()
+
 	// OSシグナル；Ctrl+Cによる終了信号など、に基づいて処理をキャンセル可能なコンテキストctxを生成、サーバーまたはクライアントの実行中にシグナルが発生した場合に適切に処理を終了させるため
-	ctx := SignalContext(context.Background())
 	var err error
+	if err = setupLogging(); err != nil {
+		logger.Error("invalid logging flags", "err", err)
+		os.Exit(2)
+	}
+	ctx := SignalContext(context.Background())
 
 	// コマンドライン引数で指定されたモード（serverMode変数の値）に応じて、プログラムをサーバーモードまたはクライアントモードで実行。サーバーモードではServer関数を、クライアントモードではClient関数を呼び出し、それぞれのRunメソッドをctxを引数にして実行
-	if serverMode {
-		DebugLogf("server mode")
-		err = Server(host, password).Run(ctx)
+	if flag.Arg(0) == "gen-certs" {
+		err = genCerts(flag.Args()[1:])
+	} else if serverMode {
+		logger.Debug("server mode")
+		var s *server
+		if s, err = serverFromFlags(); err == nil {
+			err = s.Run(ctx)
+		}
+	} else if httpAddr != "" {
+		logger.Debug("bridge mode")
+		var b *bridge
+		if b, err = bridgeFromFlags(); err == nil {
+			err = b.Run(ctx)
+		}
 	} else {
-		DebugLogf("client mode")
-		err = Client(host, password, username).Run(ctx)
+		logger.Debug("client mode")
+		var c *client
+		if c, err = clientFromFlags(); err == nil {
+			if tuiMode {
+				err = runTUIFromFlags(ctx, c)
+			} else {
+				err = c.Run(ctx)
+			}
+		}
 	}
 
 	if err != nil {
-		MessageLog(time.Now(), "<<Process>>", err.Error())
+		logger.Error("exiting", "err", err)
 		os.Exit(1)
 	}
 }
+
+// -log-formatと-log-file、-vの設定を全体のロガーに反映する関数
+func setupLogging() error {
+	var w io.Writer
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return errors.WithMessage(err, "unable to open log file")
+		}
+		w = f
+	}
+	return configureLogging(w, logFormat, debugMode)
+}
+
+// コマンドライン引数の設定を反映したサーバ構造体を生成する関数
+func serverFromFlags() (*server, error) {
+	s := Server(host, password)
+	s.Replay = replaySize
+	s.TokenTTL = tokenTTL
+
+	policy, err := parseBackpressurePolicy(backpressure)
+	if err != nil {
+		return nil, err
+	}
+	s.Backpressure = policy
+	s.BlockTimeout = blockTimeout
+	s.MetricsAddr = metricsAddr
+	s.ShutdownGrace = shutdownGrace
+	s.SessionsFile = sessionsFile
+	s.Limits = newRateLimits(rateMessages, rateBurst, maxMessageBytes, loginRate)
+	s.Limits.setUploadQuota(uploadQuota)
+	if s.TrustedProxies, err = parseTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+
+	creds, err := flagTLS().ServerCredentials()
+	if err != nil {
+		return nil, err
+	}
+	s.Creds = creds
+
+	broker, err := parseBroker(brokerURL)
+	if err != nil {
+		return nil, err
+	}
+	s.Broker = broker
+
+	if tokenSecret != "" {
+		tokens, err := token.NewHMACMaker(tokenSecret)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid token secret")
+		}
+		s.Tokens = tokens
+	}
+
+	for _, name := range strings.Split(moderators, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			s.Moderators[name] = true
+		}
+	}
+	if bansFile != "" {
+		bans, err := loadBans(bansFile)
+		if err != nil {
+			return nil, err
+		}
+		s.Bans = bans
+	}
+
+	if blobDir != "" {
+		blobs, err := newBlobStore(blobDir, maxUpload)
+		if err != nil {
+			return nil, err
+		}
+		s.Blobs = blobs
+	}
+
+	if usersFile != "" {
+		users, err := loadUsers(usersFile)
+		if err != nil {
+			return nil, err
+		}
+		s.Users = users
+	}
+
+	if historyFile != "" {
+		store, err := newFileStore(historyFile, historySize, historyMax)
+		if err != nil {
+			return nil, err
+		}
+		s.Store = store
+	} else {
+		s.Store = newRingStore(historySize)
+	}
+
+	return s, nil
+}
+
+// コマンドライン引数の設定を反映したクライアント構造体を生成する関数
+func clientFromFlags() (*client, error) {
+	c := Client(host, password, username)
+	c.Register = register
+	c.MaxReconnects = maxReconnects
+	if !tuiMode {
+		c.Transcript = terminalTranscript()
+	}
+
+	creds, err := flagTLS().ClientCredentials(host)
+	if err != nil {
+		return nil, err
+	}
+	c.Creds = creds
+
+	return c, nil
+}
+
+// コマンドライン引数の設定を反映したブリッジ構造体を生成する関数
+func bridgeFromFlags() (*bridge, error) {
+	b := Bridge(httpAddr, host)
+
+	creds, err := flagTLS().ClientCredentials(host)
+	if err != nil {
+		return nil, err
+	}
+	b.Creds = creds
+
+	return b, nil
+}
+
+// 端末の画面を開いて全画面の端末UIでクライアントを実行する関数
+func runTUIFromFlags(ctx context.Context, c *client) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return errors.WithMessage(err, "unable to open the terminal")
+	}
+	return c.RunTUI(ctx, screen)
+}
+
+// コマンドライン引数で指定されたTLSの設定
+func flagTLS() tlsConfig {
+	return tlsFlags
+}
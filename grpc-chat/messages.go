@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// 編集や削除のために送信者を覚えておくメッセージの件数
+	maxTrackedMessages = defaultHistorySize
+	// リアクションに使える文字列の最大の長さ(バイト)
+	maxEmojiLength = 32
+)
+
+// 編集や削除の権限を確認するためのメッセージの情報
+type messageInfo struct {
+	Author, Room string
+	Deleted      bool
+	// 絵文字をキーとしてリアクションを付けたユーザ名の集合を保持
+	Reactions map[string]map[string]bool
+}
+
+// メッセージIDを生成する関数、複数のレプリカで同時に生成しても重ならないようランダムにする
+// 重なると履歴や検索の索引の別のメッセージを上書きしてしまうので、UUIDと同じ128ビットにする
+func newMessageID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// 配信するメッセージとその編集・削除・リアクションを記録するメソッド、古いメッセージから忘れる
+func (s *server) trackMessage(res *chat.StreamResponse) {
+	s.messagesMtx.Lock()
+	defer s.messagesMtx.Unlock()
+
+	switch evt := res.Event.(type) {
+	case *chat.StreamResponse_ClientMessage:
+		msg := evt.ClientMessage
+		if msg.Id == "" {
+			return
+		}
+		if _, ok := s.Messages[msg.Id]; !ok {
+			s.messageOrder = append(s.messageOrder, msg.Id)
+		}
+		s.Messages[msg.Id] = &messageInfo{Author: msg.Name, Room: msg.Room, Reactions: make(map[string]map[string]bool)}
+		for len(s.messageOrder) > maxTrackedMessages {
+			delete(s.Messages, s.messageOrder[0])
+			s.messageOrder = s.messageOrder[1:]
+		}
+	case *chat.StreamResponse_MessageDeleted:
+		if info, ok := s.Messages[evt.MessageDeleted.Id]; ok {
+			info.Deleted = true
+		}
+	case *chat.StreamResponse_MessageReaction:
+		r := evt.MessageReaction
+		info, ok := s.Messages[r.Id]
+		if !ok {
+			return
+		}
+		if r.Removed {
+			delete(info.Reactions[r.Emoji], r.Name)
+			if len(info.Reactions[r.Emoji]) == 0 {
+				delete(info.Reactions, r.Emoji)
+			}
+			return
+		}
+		if info.Reactions[r.Emoji] == nil {
+			info.Reactions[r.Emoji] = make(map[string]bool)
+		}
+		info.Reactions[r.Emoji][r.Name] = true
+	}
+}
+
+// メッセージの送信者とルームを返すメソッド、削除されたものや覚えていないものはfalse
+func (s *server) getMessage(id string) (author, room string, ok bool) {
+	s.messagesMtx.Lock()
+	defer s.messagesMtx.Unlock()
+
+	m, ok := s.Messages[id]
+	if !ok || m.Deleted {
+		return "", "", false
+	}
+	return m.Author, m.Room, true
+}
+
+// ユーザがメッセージに既に同じリアクションを付けているかを確認するメソッド
+func (s *server) hasReacted(id, emoji, name string) bool {
+	s.messagesMtx.Lock()
+	defer s.messagesMtx.Unlock()
+
+	m, ok := s.Messages[id]
+	return ok && m.Reactions[emoji][name]
+}
+
+// 編集や削除ができるメッセージのルームを返すメソッド、できないときは送信者にエラーイベントを返してfalseを返す
+func (s *server) ownMessage(tkn, name, id string) (room string, ok bool) {
+	author, room, ok := s.getMessage(id)
+	switch {
+	case !ok:
+		s.sendError(tkn, fmt.Sprintf("message %q not found", id))
+		return "", false
+	case author != name && !s.Moderators[name]:
+		s.sendError(tkn, "only the author or a moderator can change this message")
+		return "", false
+	}
+	return room, true
+}
+
+// メッセージを書き換えるメソッド
+func (s *server) editMessage(tkn, name string, req *chat.StreamRequest_Edit) {
+	room, ok := s.ownMessage(tkn, name, req.Id)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		s.sendError(tkn, "message cannot be empty, delete it instead")
+		return
+	}
+
+	s.Broadcast <- &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_MessageEdited{
+			MessageEdited: &chat.StreamResponse_Edited{Id: req.Id, Name: name, Message: req.Message, Room: room},
+		},
+	}
+}
+
+// メッセージを削除するメソッド
+func (s *server) deleteMessage(tkn, name string, req *chat.StreamRequest_Delete) {
+	room, ok := s.ownMessage(tkn, name, req.Id)
+	if !ok {
+		return
+	}
+
+	s.Broadcast <- &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_MessageDeleted{
+			MessageDeleted: &chat.StreamResponse_Deleted{Id: req.Id, Name: name, Room: room},
+		},
+	}
+}
+
+// メッセージにリアクションを付ける、または取り消すメソッド、メッセージのルームの参加者のみ
+func (s *server) react(tkn, name string, req *chat.StreamRequest_Reaction) {
+	_, room, ok := s.getMessage(req.Id)
+	switch {
+	case !ok:
+		s.sendError(tkn, fmt.Sprintf("message %q not found", req.Id))
+		return
+	case !s.inRoom(room, tkn):
+		s.sendError(tkn, fmt.Sprintf("not a member of room %q", room))
+		return
+	case req.Emoji == "" || len(req.Emoji) > maxEmojiLength || strings.ContainsAny(req.Emoji, " \t\n"):
+		s.sendError(tkn, "invalid reaction")
+		return
+	}
+
+	s.Broadcast <- &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_MessageReaction{
+			MessageReaction: &chat.StreamResponse_Reacted{
+				Id:      req.Id,
+				Name:    name,
+				Emoji:   req.Emoji,
+				Room:    room,
+				Removed: s.hasReacted(req.Id, req.Emoji, name),
+			},
+		},
+	}
+}
+
+// 再起動後も編集や削除、検索ができるよう、履歴に残っているメッセージを記録し直すメソッド
+func (s *server) loadMessages() error {
+	history, err := s.Store.History(roomFilter{}, time.Time{}, 0)
+	if err != nil {
+		return err
+	}
+	for _, res := range history {
+		s.trackMessage(res)
+		s.Index.update(res)
+	}
+	return nil
+}
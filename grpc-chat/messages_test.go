@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDeleteReact(t *testing.T) {
+	ts := startServer(t, func(s *server) { s.Moderators["mod"] = true })
+
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+	mod := ts.startClient(t, "mod")
+
+	alice.say(t, "helo")
+	msg := bob.expect(t, "alice's message", messageFrom("alice", "helo")).GetClientMessage()
+	require.NotEmpty(t, msg.Id)
+
+	// 送信者以外は編集できない
+	bob.say(t, "/edit "+msg.Id+" hacked")
+	res := bob.expect(t, "a permission error", serverError)
+	require.Contains(t, res.GetServerError().Message, "author")
+
+	alice.say(t, "/edit "+msg.Id+" hello")
+	res = bob.expect(t, "the edit", func(res *chat.StreamResponse) bool { return res.GetMessageEdited() != nil })
+	require.Equal(t, msg.Id, res.GetMessageEdited().Id)
+	require.Equal(t, "hello", res.GetMessageEdited().Message)
+	require.NotZero(t, res.Sequence)
+
+	// 同じリアクションを2回送ると取り消しになる
+	bob.say(t, "/react "+msg.Id+" +1")
+	res = alice.expect(t, "bob's reaction", func(res *chat.StreamResponse) bool { return res.GetMessageReaction() != nil })
+	require.Equal(t, "bob", res.GetMessageReaction().Name)
+	require.False(t, res.GetMessageReaction().Removed)
+
+	bob.say(t, "/react "+msg.Id+" +1")
+	res = alice.expect(t, "bob's reaction", func(res *chat.StreamResponse) bool { return res.GetMessageReaction() != nil })
+	require.True(t, res.GetMessageReaction().Removed)
+
+	// モデレータは他人のメッセージを削除できる
+	mod.say(t, "/delete "+msg.Id)
+	res = alice.expect(t, "the deletion", func(res *chat.StreamResponse) bool { return res.GetMessageDeleted() != nil })
+	require.Equal(t, "mod", res.GetMessageDeleted().Name)
+
+	alice.say(t, "/edit "+msg.Id+" too late")
+	res = alice.expect(t, "a not found error", serverError)
+	require.Contains(t, res.GetServerError().Message, "not found")
+
+	// 編集などもルームの履歴に残る
+	history, err := ts.Store.History(roomFilter{room: defaultRoom}, time.Time{}, 0)
+	require.NoError(t, err)
+	n := 0
+	for _, res := range history {
+		if _, ok := eventRoom(res); ok {
+			n++
+		}
+	}
+	require.Equal(t, 5, n)
+}
+
+func TestTranscriptRedraws(t *testing.T) {
+	var buf bytes.Buffer
+	tr := newTranscript(&buf)
+
+	tr.message(time.Now(), &chat.StreamResponse_Message{Id: "a1", Name: "alice", Message: "helo", Room: defaultRoom})
+	tr.edited(&chat.StreamResponse_Edited{Id: "a1", Name: "alice", Message: "hello"})
+	tr.reacted(&chat.StreamResponse_Reacted{Id: "a1", Name: "bob", Emoji: "+1"})
+	tr.reacted(&chat.StreamResponse_Reacted{Id: "a1", Name: "carol", Emoji: "+1"})
+	tr.reacted(&chat.StreamResponse_Reacted{Id: "a1", Name: "bob", Emoji: "+1", Removed: true})
+	tr.deleted(&chat.StreamResponse_Deleted{Id: "a1", Name: "alice"})
+	// 覚えていないメッセージへの変更は表示しない
+	tr.edited(&chat.StreamResponse_Edited{Id: "zz", Name: "alice", Message: "ghost"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 6)
+	require.Contains(t, lines[0], "alice: helo  <a1>")
+	require.Contains(t, lines[1], "alice: hello (edited)  <a1>")
+	require.Contains(t, lines[2], "hello (edited) +1×1")
+	require.Contains(t, lines[3], "hello (edited) +1×2")
+	require.Contains(t, lines[4], "hello (edited) +1×1")
+	require.Contains(t, lines[5], "(message deleted)  <a1>")
+	require.NotContains(t, buf.String(), "ghost")
+	require.Equal(t, 6, tr.lines)
+}
+
+func TestNewMessageID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := newMessageID()
+		require.Len(t, id, 32)
+		require.False(t, seen[id], "duplicate id %s", id)
+		seen[id] = true
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+
+	"grpc-chat/session"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// クライアントとサーバの間でgRPCのメタデータとしてやり取りするヘッダ、ボットとも共有するのでsessionパッケージで定義する
+// 送る側と受け取る側は必ずここかsessionパッケージの関数を使う
+const (
+	tokenHeader    = session.TokenHeader
+	resumeHeader   = session.ResumeHeader
+	clientIPHeader = session.ClientIPHeader
+)
+
+// クライアントが送るメタデータを付与したコンテキストを返す関数、lastSeqが0のときは再開位置を送らない
+func outgoingContext(ctx context.Context, tkn string, lastSeq uint64) context.Context {
+	return session.OutgoingContext(ctx, tkn, lastSeq)
+}
+
+// 受け取ったメタデータからトークンを取り出す関数
+func extractToken(ctx context.Context) (tkn string, ok bool) {
+	// コンテキストからメタデータを取得するメソッド、gRPCのrクエストにはメタデータが含まれており、mdに取得したメタデータを格納
+	md, ok := metadata.FromIncomingContext(ctx)
+	// トークンが見つからなかったときの処理
+	if !ok || len(md.Get(tokenHeader)) == 0 {
+		return "", false
+	}
+	// 通常、ヘッダーには1つの値しか含まれないため、最初の値 [0] を取得
+	return md.Get(tokenHeader)[0], true
+}
+
+// 再接続したクライアントが最後に受け取ったシーケンス番号をメタデータから取り出す関数、無いときは0
+func extractSequence(ctx context.Context) uint64 {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(resumeHeader)) == 0 {
+		return 0
+	}
+	seq, _ := strconv.ParseUint(md.Get(resumeHeader)[0], 10, 64)
+	return seq
+}
+
+// ブリッジが中継するリクエストの送信元のIPアドレスをメタデータに付与したコンテキストを返す関数
+func withClientIP(ctx context.Context, ip string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, clientIPHeader, ip)
+}
+
+// ブリッジが送った送信元のIPアドレスをメタデータから取り出す関数
+func extractClientIP(ctx context.Context) (ip string, ok bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(clientIPHeader)) == 0 {
+		return "", false
+	}
+	return md.Get(clientIPHeader)[0], true
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+	"grpc-chat/token"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// モデレータだけが使えるサーバ側のコマンドと、その操作
+var moderationCommands = map[string]chat.StreamResponse_Moderation_Action{
+	"/kick":   chat.StreamResponse_Moderation_KICK,
+	"/ban":    chat.StreamResponse_Moderation_BAN,
+	"/unban":  chat.StreamResponse_Moderation_UNBAN,
+	"/mute":   chat.StreamResponse_Moderation_MUTE,
+	"/unmute": chat.StreamResponse_Moderation_UNMUTE,
+	"/topic":  chat.StreamResponse_Moderation_TOPIC,
+}
+
+// 禁止されたユーザ名とその期限を保持し、JSONファイルに保存する一覧、期限がゼロ値のときは無期限
+type banList struct {
+	// 空のときはファイルに保存せず、再起動すると消える
+	path string
+	bans map[string]time.Time
+	mtx  sync.RWMutex
+}
+
+// ファイルに保存しない空の一覧を生成する関数
+func newBanList() *banList {
+	return &banList{bans: make(map[string]time.Time)}
+}
+
+// pathのJSONファイルから一覧を読み込む関数、ファイルが無いときは空の一覧を返し、最初の変更時に作成する
+func loadBans(path string) (*banList, error) {
+	b := newBanList()
+	b.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	} else if err != nil {
+		return nil, errors.WithMessage(err, "unable to read ban file")
+	}
+
+	if err := json.Unmarshal(data, &b.bans); err != nil {
+		return nil, errors.WithMessage(err, "unable to parse ban file")
+	}
+	return b, nil
+}
+
+// ユーザをuntilまで禁止するメソッド
+func (b *banList) Ban(name string, until time.Time) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.bans[name] = until
+	return b.save()
+}
+
+// ユーザの禁止を解除するメソッド
+func (b *banList) Unban(name string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	delete(b.bans, name)
+	return b.save()
+}
+
+// ユーザが禁止されているかとその期限を返すメソッド
+func (b *banList) Banned(name string) (until time.Time, ok bool) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	until, ok = b.bans[name]
+	if ok && !until.IsZero() && time.Now().After(until) {
+		return time.Time{}, false
+	}
+	return until, ok
+}
+
+// 一覧をファイルに書き出すメソッド、期限の切れたものはここで取り除く
+func (b *banList) save() error {
+	now := time.Now()
+	for name, until := range b.bans {
+		if !until.IsZero() && now.After(until) {
+			delete(b.bans, name)
+		}
+	}
+
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.bans, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "unable to encode ban file")
+	}
+	return errors.WithMessage(writeFileAtomic(b.path, data), "unable to write ban file")
+}
+
+// 発言が"/"で始まるときにサーバ側のコマンドとして処理するメソッド、コマンドとして処理した場合はtrueを返す
+func (s *server) command(tkn, name, room, line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	action, ok := moderationCommands[cmd]
+	if !ok {
+		return false
+	}
+	if !s.Moderators[name] {
+		s.sendError(tkn, fmt.Sprintf("%s can only be used by a moderator", cmd))
+		return true
+	}
+
+	mod := &chat.StreamResponse_Moderation{Action: action, Moderator: name}
+
+	if action == chat.StreamResponse_Moderation_TOPIC {
+		mod.Room, mod.Topic = room, strings.TrimSpace(arg)
+		s.Broadcast <- moderation(mod)
+		return true
+	}
+
+	args := strings.Fields(arg)
+	timed := action == chat.StreamResponse_Moderation_BAN || action == chat.StreamResponse_Moderation_MUTE
+	switch {
+	case len(args) == 0, len(args) > 2, len(args) == 2 && !timed:
+		if timed {
+			s.sendError(tkn, fmt.Sprintf("usage: %s <name> [duration]", cmd))
+		} else {
+			s.sendError(tkn, fmt.Sprintf("usage: %s <name>", cmd))
+		}
+		return true
+	case len(args) == 2:
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 {
+			s.sendError(tkn, fmt.Sprintf("invalid duration %q", args[1]))
+			return true
+		}
+		mod.Until = timestamppb.New(time.Now().Add(d))
+	}
+	mod.Target = args[0]
+
+	// 他のレプリカに接続しているユーザはこのレプリカからは見えないので、プロセス外のブローカーを使うときは確認しない
+	if _, local := s.Broker.(*localBroker); local && action == chat.StreamResponse_Moderation_KICK && len(s.getTokens(mod.Target)) == 0 {
+		s.sendError(tkn, fmt.Sprintf("%s is not logged in", mod.Target))
+		return true
+	}
+
+	s.Broadcast <- moderation(mod)
+
+	// キックと禁止は対象のユーザのセッションを終了させる
+	switch action {
+	case chat.StreamResponse_Moderation_KICK:
+		s.Broadcast <- kicked(mod.Target, name, "kicked by "+name)
+	case chat.StreamResponse_Moderation_BAN:
+		s.Broadcast <- kicked(mod.Target, name, "banned by "+name+untilText(mod.Until))
+	}
+	return true
+}
+
+// モデレーションのイベントを生成する関数
+func moderation(mod *chat.StreamResponse_Moderation) *chat.StreamResponse {
+	return &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event:     &chat.StreamResponse_ModerationAction{ModerationAction: mod},
+	}
+}
+
+// セッションを終了させられたことを対象のユーザに知らせるイベントを生成する関数
+func kicked(name, by, reason string) *chat.StreamResponse {
+	return &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_ClientKicked{
+			ClientKicked: &chat.StreamResponse_Kicked{Name: name, By: by, Reason: reason},
+		},
+	}
+}
+
+// 期限をイベントで使う形式にする関数、無期限(ゼロ値)のときはnil
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// 期限を表示用の文字列にする関数、無期限のときは空
+func untilText(until *timestamppb.Timestamp) string {
+	if until == nil {
+		return ""
+	}
+	return " until " + until.AsTime().In(time.Local).Format(time.RFC3339)
+}
+
+// 配信するモデレーションのイベントをこのレプリカに反映するメソッド、キックされたユーザのセッションは配信の後にendSessionsで終了させる
+func (s *server) applyModeration(mod *chat.StreamResponse_Moderation) {
+	var until time.Time
+	if mod.Until != nil {
+		until = mod.Until.AsTime()
+	}
+
+	var err error
+	switch mod.Action {
+	case chat.StreamResponse_Moderation_BAN:
+		err = s.Bans.Ban(mod.Target, until)
+	case chat.StreamResponse_Moderation_UNBAN:
+		err = s.Bans.Unban(mod.Target)
+	case chat.StreamResponse_Moderation_MUTE:
+		s.mutesMtx.Lock()
+		s.Mutes[mod.Target] = until
+		s.mutesMtx.Unlock()
+	case chat.StreamResponse_Moderation_UNMUTE:
+		s.mutesMtx.Lock()
+		delete(s.Mutes, mod.Target)
+		s.mutesMtx.Unlock()
+	case chat.StreamResponse_Moderation_TOPIC:
+		s.roomsMtx.Lock()
+		s.Topics[mod.Room] = mod.Topic
+		s.roomsMtx.Unlock()
+	}
+	if err != nil {
+		s.Log.Error("failed to apply moderation", "err", err)
+	}
+
+	if mod.Action == chat.StreamResponse_Moderation_TOPIC {
+		s.Log.Info("set topic", "name", mod.Moderator, "room", mod.Room, "topic", mod.Topic)
+	} else {
+		attrs := []any{"name", mod.Moderator, "action", mod.Action.String(), "target", mod.Target}
+		if !until.IsZero() {
+			attrs = append(attrs, "until", until)
+		}
+		s.Log.Info("moderation", attrs...)
+	}
+}
+
+// ユーザが発言を禁止されているかとその期限を返すメソッド
+func (s *server) muted(name string) (until time.Time, ok bool) {
+	s.mutesMtx.Lock()
+	defer s.mutesMtx.Unlock()
+
+	until, ok = s.Mutes[name]
+	if ok && !until.IsZero() && time.Now().After(until) {
+		delete(s.Mutes, name)
+		return time.Time{}, false
+	}
+	return until, ok
+}
+
+// 指定したユーザのこのレプリカ上の全てのセッションを終了させるメソッド、同じトークンで再開できないよう失効させる
+func (s *server) endSessions(name string) {
+	for _, tkn := range s.getTokens(name) {
+		expiry := s.getPresence(tkn).Expiry
+		if expiry.IsZero() {
+			expiry = time.Now().Add(s.TokenTTL)
+		}
+		s.delName(tkn)
+		s.leaveAllRooms(tkn)
+		s.delPresence(tkn, name)
+		s.revoke(&token.Payload{ID: tkn, Username: name, ExpiredAt: expiry})
+
+		s.Log.Info("kicked", "token", tkn, "name", name)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBanListPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.json")
+
+	bans, err := loadBans(path)
+	require.NoError(t, err)
+	require.NoError(t, bans.Ban("alice", time.Time{}))
+	require.NoError(t, bans.Ban("bob", time.Now().Add(time.Hour)))
+	require.NoError(t, bans.Ban("carol", time.Now().Add(-time.Second)))
+
+	// 再起動後も禁止は残り、期限の切れたものは取り除かれる
+	bans, err = loadBans(path)
+	require.NoError(t, err)
+
+	until, ok := bans.Banned("alice")
+	require.True(t, ok)
+	require.True(t, until.IsZero())
+
+	until, ok = bans.Banned("bob")
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(time.Hour), until, time.Minute)
+
+	_, ok = bans.Banned("carol")
+	require.False(t, ok)
+
+	require.NoError(t, bans.Unban("alice"))
+	bans, err = loadBans(path)
+	require.NoError(t, err)
+	_, ok = bans.Banned("alice")
+	require.False(t, ok)
+}
+
+func moderationOf(action chat.StreamResponse_Moderation_Action, target string) func(*chat.StreamResponse) bool {
+	return func(res *chat.StreamResponse) bool {
+		mod := res.GetModerationAction()
+		return mod.GetAction() == action && mod.GetTarget() == target
+	}
+}
+
+func serverError(res *chat.StreamResponse) bool { return res.GetServerError() != nil }
+
+func TestModerationCommands(t *testing.T) {
+	ts := startServer(t, func(s *server) { s.Moderators["mod"] = true })
+
+	mod := ts.startClient(t, "mod")
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+
+	// モデレータ以外はコマンドを使えない
+	alice.say(t, "/kick bob")
+	res := alice.expect(t, "a permission error", serverError)
+	require.Contains(t, res.GetServerError().Message, "moderator")
+
+	// 発言を禁止されている間のメッセージは配信されない
+	mod.say(t, "/mute alice 1h")
+	bob.expect(t, "alice's mute", moderationOf(chat.StreamResponse_Moderation_MUTE, "alice"))
+	alice.say(t, "can anyone hear me")
+	res = alice.expect(t, "a mute error", serverError)
+	require.Contains(t, res.GetServerError().Message, "muted")
+
+	mod.say(t, "/unmute alice")
+	bob.expect(t, "alice's unmute", moderationOf(chat.StreamResponse_Moderation_UNMUTE, "alice"))
+	alice.say(t, "back again")
+	res = bob.expect(t, "alice's message", func(res *chat.StreamResponse) bool { return res.GetClientMessage().GetName() == "alice" })
+	require.Equal(t, "back again", res.GetClientMessage().Message)
+
+	mod.say(t, "/topic release day")
+	bob.expect(t, "the topic", moderationOf(chat.StreamResponse_Moderation_TOPIC, ""))
+	rooms, err := ts.ListRooms(context.Background(), new(chat.ListRoomsRequest))
+	require.NoError(t, err)
+	require.Equal(t, "release day", rooms.Rooms[0].Topic)
+
+	// キックされたクライアントは再接続もログアウトもせずに終了する
+	mod.say(t, "/kick bob")
+	res = bob.expect(t, "the kick", func(res *chat.StreamResponse) bool { return res.GetClientKicked() != nil })
+	require.Equal(t, "mod", res.GetClientKicked().By)
+	require.NoError(t, bob.wait(t))
+	require.True(t, bob.Kicked)
+	alice.expect(t, "bob's kick", moderationOf(chat.StreamResponse_Moderation_KICK, "bob"))
+	require.Empty(t, ts.getTokens("bob"))
+
+	// 禁止されたユーザはログインもできない
+	mod.say(t, "/ban alice 1h")
+	alice.expect(t, "the ban", func(res *chat.StreamResponse) bool { return res.GetClientKicked() != nil })
+	require.NoError(t, alice.wait(t))
+
+	_, err = chat.NewChatClient(ts.conn(t)).Login(context.Background(), &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
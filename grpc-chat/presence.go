@@ -0,0 +1,177 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// 最後の操作からこの時間が経つとIDLEとする
+	idleAfter = 5 * time.Minute
+	// 最後の操作からこの時間が経つとAWAYとする
+	awayAfter = 30 * time.Minute
+)
+
+// セッションごとのログイン時刻と最後に操作した時刻、トークンの有効期限
+type presence struct {
+	LoginTime, LastActive, Expiry time.Time
+	// ログイン時に公開されたエンドツーエンド暗号化の公開鍵
+	PublicKey []byte
+}
+
+// ログイン中のユーザとその状態の一覧を返すメソッド
+func (s *server) ListUsers(_ context.Context, _ *chat.ListUsersRequest) (*chat.ListUsersResponse, error) {
+	now := time.Now()
+
+	s.namesMtx.RLock()
+	sessions := make(map[string]string, len(s.ClientNames))
+	for tkn, name := range s.ClientNames {
+		sessions[tkn] = name
+	}
+	s.namesMtx.RUnlock()
+
+	users := make([]*chat.ListUsersResponse_User, 0, len(sessions))
+	for tkn, name := range sessions {
+		p := s.getPresence(tkn)
+
+		s.streamsMtx.RLock()
+		_, connected := s.ClientStreams[tkn]
+		s.streamsMtx.RUnlock()
+
+		users = append(users, &chat.ListUsersResponse_User{
+			Name:       name,
+			LoginTime:  timestamppb.New(p.LoginTime),
+			LastActive: timestamppb.New(p.LastActive),
+			Status:     presenceStatus(now.Sub(p.LastActive)),
+			Connected:  connected,
+		})
+	}
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+
+	return &chat.ListUsersResponse{Users: users}, nil
+}
+
+// 最後の操作からの経過時間でユーザの状態を判定する関数
+func presenceStatus(idle time.Duration) chat.ListUsersResponse_Status {
+	switch {
+	case idle >= awayAfter:
+		return chat.ListUsersResponse_AWAY
+	case idle >= idleAfter:
+		return chat.ListUsersResponse_IDLE
+	default:
+		return chat.ListUsersResponse_ACTIVE
+	}
+}
+
+// ログインしたセッションの状態を登録するメソッド
+func (s *server) setPresence(tkn string, expiry time.Time) {
+	now := time.Now()
+	s.presenceMtx.Lock()
+	s.Presence[tkn] = &presence{LoginTime: now, LastActive: now, Expiry: expiry}
+	s.presenceMtx.Unlock()
+}
+
+// セッションが公開したエンドツーエンド暗号化の公開鍵を登録するメソッド、他のレプリカのユーザからも引けるようブローカーにも登録する
+func (s *server) setPublicKey(tkn, name string, key []byte) {
+	s.presenceMtx.Lock()
+	if p, ok := s.Presence[tkn]; ok {
+		p.PublicKey = key
+	}
+	s.presenceMtx.Unlock()
+
+	if len(key) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := s.Broker.SetPublicKey(ctx, name, key); err != nil {
+		s.Log.Warn("unable to share public key", "token", tkn, "name", name, "err", err)
+	}
+}
+
+// トークンを更新したセッションの有効期限を延長するメソッド
+func (s *server) setExpiry(tkn string, expiry time.Time) {
+	s.presenceMtx.Lock()
+	if p, ok := s.Presence[tkn]; ok {
+		p.Expiry = expiry
+	}
+	s.presenceMtx.Unlock()
+}
+
+// トークンの有効期限が切れたまま残っている同名のセッションを削除するメソッド、再接続できなくなったクライアントが再ログインできるようにする
+func (s *server) evictExpired(name string) {
+	now := time.Now()
+	for _, tkn := range s.getTokens(name) {
+		if p := s.getPresence(tkn); p.Expiry.IsZero() || now.Before(p.Expiry) {
+			continue
+		}
+		s.delName(tkn)
+		s.leaveAllRooms(tkn)
+		s.delPresence(tkn, name)
+		s.Log.Debug("evicted expired session", "token", tkn, "name", name)
+	}
+}
+
+// セッションの状態のコピーを返すメソッド
+func (s *server) getPresence(tkn string) presence {
+	s.presenceMtx.Lock()
+	defer s.presenceMtx.Unlock()
+
+	if p, ok := s.Presence[tkn]; ok {
+		return *p
+	}
+	return presence{}
+}
+
+// セッションの最後に操作した時刻を更新するメソッド
+func (s *server) touch(tkn string) {
+	s.presenceMtx.Lock()
+	if p, ok := s.Presence[tkn]; ok {
+		p.LastActive = time.Now()
+	}
+	s.presenceMtx.Unlock()
+}
+
+// ログアウトしたセッションの状態を削除するメソッド、ブローカーに登録した公開鍵も取り下げる
+func (s *server) delPresence(tkn, name string) {
+	s.presenceMtx.Lock()
+	var key []byte
+	if p, ok := s.Presence[tkn]; ok {
+		key = p.PublicKey
+	}
+	delete(s.Presence, tkn)
+	s.presenceMtx.Unlock()
+
+	if len(key) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := s.Broker.DeletePublicKey(ctx, name, key); err != nil {
+		s.Log.Warn("unable to withdraw public key", "token", tkn, "name", name, "err", err)
+	}
+}
+
+// 入力中であること、または入力をやめたことをルームの参加者に知らせるメソッド
+func (s *server) typing(tkn, name string, req *chat.StreamRequest) {
+	room := normalizeRoom(req.Room)
+	if !s.inRoom(room, tkn) {
+		return
+	}
+
+	state := &chat.StreamResponse_TypingState{Name: name, Room: room}
+	res := &chat.StreamResponse{Timestamp: timestamppb.Now()}
+	if req.GetStopTyping() != nil {
+		res.Event = &chat.StreamResponse_StoppedTyping{StoppedTyping: state}
+	} else {
+		res.Event = &chat.StreamResponse_Typing{Typing: state}
+	}
+
+	s.Broadcast <- res
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPresenceStatus(t *testing.T) {
+	for idle, want := range map[time.Duration]chat.ListUsersResponse_Status{
+		0:                       chat.ListUsersResponse_ACTIVE,
+		idleAfter - time.Second: chat.ListUsersResponse_ACTIVE,
+		idleAfter:               chat.ListUsersResponse_IDLE,
+		awayAfter - time.Second: chat.ListUsersResponse_IDLE,
+		awayAfter:               chat.ListUsersResponse_AWAY,
+		24 * time.Hour:          chat.ListUsersResponse_AWAY,
+	} {
+		require.Equal(t, want, presenceStatus(idle), "idle for %s", idle)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	ts := startServer(t)
+	alice := ts.startClient(t, "alice")
+	ts.startClient(t, "bob")
+
+	// ストリームを開かずにログインしただけのセッション
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	_, err := cc.Login(ctx, &chat.LoginRequest{Name: "carol", Password: testPassword})
+	require.NoError(t, err)
+
+	// bobは最後の操作から時間が経っている
+	for _, tkn := range ts.getTokens("bob") {
+		ts.presenceMtx.Lock()
+		ts.Presence[tkn].LastActive = time.Now().Add(-idleAfter - time.Minute)
+		ts.presenceMtx.Unlock()
+	}
+
+	res, err := cc.ListUsers(outgoingContext(ctx, alice.token(), 0), &chat.ListUsersRequest{})
+	require.NoError(t, err)
+	var got []string
+	for _, u := range res.Users {
+		require.NotNil(t, u.LoginTime)
+		got = append(got, fmt.Sprintf("%s %s %t", u.Name, u.Status, u.Connected))
+	}
+	require.Equal(t, []string{"alice ACTIVE true", "bob IDLE true", "carol ACTIVE false"}, got)
+}
+
+func isTyping(res *chat.StreamResponse) bool {
+	return res.GetTyping() != nil || res.GetStoppedTyping() != nil
+}
+
+func TestTyping(t *testing.T) {
+	ts := startServer(t)
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+	carol := ts.startClient(t, "carol")
+
+	payload, err := ts.Tokens.VerifyToken(alice.token())
+	require.NoError(t, err)
+	alice.say(t, "/join go")
+	bob.say(t, "/join go")
+	require.Eventually(t, func() bool { return ts.inRoom("go", payload.ID) }, testTimeout, 10*time.Millisecond)
+
+	// TUIの入力欄と同じく、打鍵すると入力中、送信すると入力をやめたことがルームの参加者に届く
+	alice.typing.keystroke()
+	res := bob.expect(t, "alice typing", func(res *chat.StreamResponse) bool { return res.GetTyping() != nil })
+	require.Equal(t, "alice", res.GetTyping().Name)
+	require.Equal(t, "go", res.GetTyping().Room)
+
+	alice.typing.stop()
+	alice.say(t, "hello")
+	res = bob.expect(t, "alice stopping", func(res *chat.StreamResponse) bool { return res.GetStoppedTyping() != nil })
+	require.Equal(t, "alice", res.GetStoppedTyping().Name)
+	bob.expect(t, "alice's message", messageFrom("alice", "hello"))
+
+	// ルームに参加していないユーザには届かない
+	carol.say(t, "ping")
+	carol.expect(t, "carol's message", func(res *chat.StreamResponse) bool {
+		require.False(t, isTyping(res), "carol received %v", res)
+		return messageFrom("carol", "ping")(res)
+	})
+}
+
+func TestTypingNotifier(t *testing.T) {
+	sent := make(chan bool, 10)
+	n := newTypingNotifier(50*time.Millisecond, func(start bool) { sent <- start })
+
+	// 続けて入力しても開始は1回だけ知らせる
+	n.keystroke()
+	n.keystroke()
+	require.True(t, <-sent)
+
+	// 入力が止まると終了を知らせる
+	select {
+	case start := <-sent:
+		require.False(t, start)
+	case <-time.After(testTimeout):
+		t.Fatal("did not stop typing after going idle")
+	}
+
+	n.keystroke()
+	require.True(t, <-sent)
+	n.stop()
+	require.False(t, <-sent)
+	n.stop()
+	require.Empty(t, sent)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+// メッセージを破棄した理由、chat_messages_dropped_totalのreasonラベルの値
+const (
+	// ブローカーへの送信に失敗した
+	dropPublishFailed = "publish_failed"
+	// クライアントのストリームが一杯だった
+	dropSlowClient = "slow_client"
+)
+
+// Prometheusに公開するサーバの指標、サーバごとにレジストリを持つので1つのプロセスで複数のサーバを起動できる
+type promMetrics struct {
+	registry *prometheus.Registry
+
+	broadcast     prometheus.Counter
+	dropped       *prometheus.CounterVec
+	loginFailures *prometheus.CounterVec
+	rateLimits    *prometheus.CounterVec
+	fanOutLatency prometheus.Histogram
+}
+
+// サーバの指標を登録したpromMetricsを生成する関数、ログイン数と接続数は収集のたびにsから読み取る
+func newPromMetrics(s *server) *promMetrics {
+	m := &promMetrics{
+		registry: prometheus.NewRegistry(),
+		broadcast: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chat_messages_broadcast_total",
+			Help: "Events taken from the broadcast channel and published to the broker.",
+		}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chat_messages_dropped_total",
+			Help: "Events that were not delivered, by reason (publish_failed or slow_client).",
+		}, []string{"reason"}),
+		loginFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chat_login_failures_total",
+			Help: "Rejected logins by gRPC status code.",
+		}, []string{"code"}),
+		rateLimits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chat_rate_limited_total",
+			Help: "Requests rejected by a rate limit, by limit (messages, bytes, logins, typing or uploads).",
+		}, []string{"limit"}),
+		fanOutLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "chat_fanout_latency_seconds",
+			Help:    "Time from an event being enqueued on the broadcast channel to it being sent on a client stream.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}),
+	}
+	// 値がまだ0のラベルも一覧に出るよう、理由ごとの系列を先に作っておく
+	m.dropped.WithLabelValues(dropPublishFailed)
+	m.dropped.WithLabelValues(dropSlowClient)
+	for _, limit := range []string{limitMessages, limitBytes, limitLogins, limitTyping, limitUploads} {
+		m.rateLimits.WithLabelValues(limit)
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "chat_active_logins",
+			Help: "Sessions that are currently logged in.",
+		}, func() float64 {
+			s.namesMtx.RLock()
+			defer s.namesMtx.RUnlock()
+			return float64(len(s.ClientNames))
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "chat_open_streams",
+			Help: "Client streams currently open on this replica.",
+		}, func() float64 {
+			s.streamsMtx.RLock()
+			defer s.streamsMtx.RUnlock()
+			return float64(len(s.ClientStreams))
+		}),
+		m.broadcast,
+		m.dropped,
+		m.loginFailures,
+		m.rateLimits,
+		m.fanOutLatency,
+	)
+	return m
+}
+
+// /metricsで指標を返すハンドラを返すメソッド
+func (m *promMetrics) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// 拒否したログインをステータスコードごとに数えるメソッド
+func (m *promMetrics) loginFailed(code codes.Code) {
+	m.loginFailures.WithLabelValues(code.String()).Inc()
+}
+
+// 制限に掛かって拒否したリクエストを制限ごとに数えるメソッド
+func (m *promMetrics) rateLimited(limit string) {
+	m.rateLimits.WithLabelValues(limit).Inc()
+}
+
+// クライアントへ送信したイベントについて、ブロードキャストチャネルに入れてからの時間を記録するメソッド
+// 入れた時刻にはイベントのTimestampを使うので、他のレプリカから届いたイベントには時計のずれも含まれる
+func (m *promMetrics) observeFanOut(res *chat.StreamResponse) {
+	if res == nil || res.Timestamp == nil {
+		return
+	}
+	m.fanOutLatency.Observe(time.Since(res.Timestamp.AsTime()).Seconds())
+}
+
+// MetricsAddrで/metricsの待ち受けを開始するメソッド、ctxが終了したら停止する
+func (s *server) serveMetrics(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.MetricsAddr)
+	if err != nil {
+		return errors.WithMessage(err, "unable to bind metrics listener")
+	}
+
+	srv := &http.Server{Handler: s.Prometheus.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		if err := srv.Serve(lis); err != http.ErrServerClosed {
+			s.Log.Error("metrics listener stopped", "err", err)
+		}
+	}()
+
+	s.Log.Info("serving metrics", "url", "http://"+lis.Addr().String()+"/metrics")
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	ts := startServer(t)
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	_, err := chat.NewChatClient(ts.conn(t)).Login(ctx, &chat.LoginRequest{Name: "mallory", Password: "wrong"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	alice.say(t, "hello")
+	bob.expect(t, "alice's message", messageFrom("alice", "hello"))
+
+	m := ts.Prometheus
+	require.Equal(t, 1.0, testutil.ToFloat64(m.loginFailures.WithLabelValues(codes.Unauthenticated.String())))
+	require.Equal(t, 0.0, testutil.ToFloat64(m.dropped.WithLabelValues(dropSlowClient)))
+	// 2人のログインと1件のメッセージ、カウンタはブローカーへの送信を終えてから増えるので配信より遅れることがある
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(m.broadcast) >= 3 && histogramCount(m) >= 3
+	}, testTimeout, 10*time.Millisecond)
+
+	// /metricsはテキスト形式で全ての指標を返す
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	for _, line := range []string{
+		"chat_active_logins 2",
+		"chat_open_streams 2",
+		`chat_login_failures_total{code="Unauthenticated"} 1`,
+		`chat_messages_dropped_total{reason="publish_failed"} 0`,
+		"chat_fanout_latency_seconds_bucket",
+		"go_goroutines",
+	} {
+		require.Contains(t, string(body), line)
+	}
+}
+
+// ファンアウトの遅延を記録した件数を返す関数
+func histogramCount(m *promMetrics) uint64 {
+	families, _ := m.registry.Gather()
+	for _, f := range families {
+		if f.GetName() == "chat_fanout_latency_seconds" {
+			return f.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
+
+func TestObserveFanOutWithoutTimestamp(t *testing.T) {
+	m := newPromMetrics(Server("bufnet", testPassword))
+
+	// 時刻の無いイベントやnilを渡してもパニックしない
+	require.NotPanics(t, func() {
+		m.observeFanOut(nil)
+		m.observeFanOut(new(chat.StreamResponse))
+	})
+}
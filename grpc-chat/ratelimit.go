@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// 発言とLoginの制限のデフォルト値
+const (
+	// 1セッションが1秒あたりに送れる発言の数と、まとめて送れる数
+	defaultMessageRate  = 5
+	defaultMessageBurst = 10
+	// 1件の発言の最大のバイト数
+	defaultMaxMessageBytes = 4096
+	// 1つのIPアドレスから1分あたりに試せるLoginの回数
+	defaultLoginsPerMinute = 10
+	// 1セッションが1秒あたりに送れる入力中の通知の数と、まとめて送れる数
+	defaultTypingRate  = 1
+	defaultTypingBurst = 4
+	// 1人のユーザが1時間あたりにアップロードできるバイト数
+	defaultUploadBytesPerHour = 100 << 20
+)
+
+// どの制限に掛かったか、chat_rate_limited_totalのlimitラベルの値
+const (
+	limitMessages = "messages"
+	limitBytes    = "bytes"
+	limitLogins   = "logins"
+	limitTyping   = "typing"
+	limitUploads  = "uploads"
+)
+
+// 1人のクライアントがブロードキャストチャネルを埋めて他の参加者の発言を妨げることや、共通のパスワードの総当たりを防ぐための制限
+type rateLimits struct {
+	// セッションごとの発言の頻度、nilのときは制限しない
+	messages *limiterSet
+	// 1件の発言の最大のバイト数、0以下のときは制限しない
+	maxMessageBytes int
+	// IPアドレスごとのLoginの頻度、nilのときは制限しない
+	logins *limiterSet
+	// セッションごとの入力中の通知の頻度、発言とは別に数える
+	typing *limiterSet
+	// ユーザごとのアップロードのバイト数、nilのときは制限しない
+	uploads *limiterSet
+}
+
+// 発言の頻度(1秒あたりの数とまとめて送れる数)、発言の最大のバイト数、1分あたりのLoginの回数からrateLimitsを生成する関数
+// いずれも0以下のときはその制限を無効にする、入力中の通知は常に制限し、アップロードはsetUploadQuotaで変えるまでデフォルト値で制限する
+func newRateLimits(msgsPerSec float64, burst, maxBytes, loginsPerMin int) *rateLimits {
+	l := &rateLimits{
+		maxMessageBytes: maxBytes,
+		typing:          newLimiterSet(rate.Limit(defaultTypingRate), defaultTypingBurst),
+	}
+	l.setUploadQuota(defaultUploadBytesPerHour)
+	if msgsPerSec > 0 && burst > 0 {
+		l.messages = newLimiterSet(rate.Limit(msgsPerSec), burst)
+	}
+	if loginsPerMin > 0 {
+		l.logins = newLimiterSet(rate.Limit(float64(loginsPerMin)/60), loginsPerMin)
+	}
+	return l
+}
+
+// 1人のユーザが1時間あたりにアップロードできるバイト数を設定するメソッド、0以下のときは制限しない
+func (l *rateLimits) setUploadQuota(bytesPerHour int64) {
+	l.uploads = nil
+	if bytesPerHour > 0 {
+		l.uploads = newLimiterSet(rate.Limit(float64(bytesPerHour)/3600), int(bytesPerHour))
+	}
+}
+
+// キーごとのトークンバケットの集合
+type limiterSet struct {
+	limit rate.Limit
+	burst int
+	// 満タンに戻るまでの時間、これより長く使われていないバケットは新しいものと変わらないので削除する
+	idle      time.Duration
+	entries   map[string]*limiterEntry
+	lastSweep time.Time
+	mtx       sync.Mutex
+}
+
+// 1つのキーのトークンバケットと最後に使った時刻
+type limiterEntry struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// limiterSetを生成する関数
+func newLimiterSet(limit rate.Limit, burst int) *limiterSet {
+	return &limiterSet{
+		limit:   limit,
+		burst:   burst,
+		idle:    time.Duration(float64(burst) / float64(limit) * float64(time.Second)),
+		entries: make(map[string]*limiterEntry),
+	}
+}
+
+// keyのバケットからトークンを1つ取り出すメソッド、空のときはfalseを返す
+func (l *limiterSet) allow(key string, now time.Time) bool {
+	return l.allowN(key, now, 1)
+}
+
+// keyのバケットからトークンをn個取り出すメソッド、足りないときはfalseを返す
+func (l *limiterSet) allowN(key string, now time.Time, n int) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.sweep(now)
+	e, ok := l.entries[key]
+	if !ok {
+		e = &limiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.entries[key] = e
+	}
+	e.seen = now
+	return e.limiter.AllowN(now, n)
+}
+
+// 満タンに戻ったバケットを削除するメソッド、削除は満タンに戻るまでの時間ごとに1度だけ行う
+func (l *limiterSet) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idle {
+		return
+	}
+	l.lastSweep = now
+	for key, e := range l.entries {
+		if now.Sub(e.seen) >= l.idle {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// 保持しているバケットの数を返すメソッド
+func (l *limiterSet) len() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return len(l.entries)
+}
+
+// セッションの発言が制限を超えていないか調べるメソッド、超えているときはどの制限かとクライアントへの警告を返す
+func (s *server) checkMessage(tkn string, req *chat.StreamRequest) (limit, warning string) {
+	l := s.Limits
+	if n := proto.Size(req); l.maxMessageBytes > 0 && n > l.maxMessageBytes {
+		return limitBytes, fmt.Sprintf("message is too large (%d bytes, the limit is %d)", n, l.maxMessageBytes)
+	}
+	if l.messages != nil && !l.messages.allow(tkn, time.Now()) {
+		return limitMessages, "you are sending messages too fast, slow down"
+	}
+	return "", ""
+}
+
+// セッションの入力中の通知が制限を超えていないか調べるメソッド
+// 入力中の通知は取りこぼしても害が無いので、超えたときはクライアントに知らせずに破棄する
+func (s *server) checkTyping(tkn string) bool {
+	if s.Limits.typing == nil || s.Limits.typing.allow(tkn, time.Now()) {
+		return true
+	}
+	s.Prometheus.rateLimited(limitTyping)
+	return false
+}
+
+// ユーザがsizeバイトをアップロードすると制限を超えないか調べるメソッド、超えているときはResourceExhaustedを返す
+func (s *server) checkUpload(name string, size int64) error {
+	if s.Limits.uploads == nil || s.Limits.uploads.allowN(name, time.Now(), int(size)) {
+		return nil
+	}
+	s.Prometheus.rateLimited(limitUploads)
+	s.Log.Warn("upload quota exceeded", "name", name, "size", size)
+	return status.Error(codes.ResourceExhausted, "upload quota exceeded, try again later")
+}
+
+// 接続元のIPアドレスからのLoginが制限を超えていないか調べるメソッド、超えているときはResourceExhaustedを返す
+func (s *server) checkLogin(ctx context.Context) error {
+	if s.Limits.logins == nil {
+		return nil
+	}
+	ip := s.loginIP(ctx)
+	if s.Limits.logins.allow(ip, time.Now()) {
+		return nil
+	}
+	s.Prometheus.rateLimited(limitLogins)
+	s.Log.Warn("too many login attempts", "ip", ip)
+	return status.Error(codes.ResourceExhausted, "too many login attempts, try again later")
+}
+
+// Loginの制限に使うIPアドレスを返すメソッド
+// TrustedProxiesに含まれるHTTPブリッジからのLoginは、ブリッジが送ったブラウザのIPアドレスで数える
+func (s *server) loginIP(ctx context.Context) string {
+	ip := peerIP(ctx)
+	if !s.trustedProxy(ip) {
+		return ip
+	}
+	if fwd, ok := extractClientIP(ctx); ok && net.ParseIP(fwd) != nil {
+		return fwd
+	}
+	return ip
+}
+
+// 送信元のIPアドレスを中継してくるブリッジのアドレスかを判定するメソッド
+func (s *server) trustedProxy(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, n := range s.TrustedProxies {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// カンマ区切りのIPアドレスかCIDRを解析する関数、IPアドレスはそのアドレスだけを含む範囲にする
+func parseTrustedProxies(v string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if ip := net.ParseIP(p); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.Errorf("invalid trusted proxy %q (want an IP address or CIDR)", p)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// 接続元のIPアドレスを返す関数、ポート番号を持たないアドレスはそのまま返す
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
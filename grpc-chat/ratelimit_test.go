@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestLimiterSet(t *testing.T) {
+	l := newLimiterSet(rate.Limit(1), 2)
+	now := time.Now()
+
+	// バースト分は続けて通し、その後は補充されるまで拒否する
+	require.True(t, l.allow("a", now))
+	require.True(t, l.allow("a", now))
+	require.False(t, l.allow("a", now))
+	require.True(t, l.allow("b", now))
+	require.True(t, l.allow("a", now.Add(time.Second)))
+
+	// 満タンに戻るまで使われなかったバケットは削除する
+	require.Equal(t, 2, l.len())
+	require.True(t, l.allow("c", now.Add(3*time.Second)))
+	require.Equal(t, 1, l.len())
+}
+
+func TestMessageRateLimit(t *testing.T) {
+	ts := startServer(t, func(s *server) { s.Limits = newRateLimits(0.01, 2, 64, 0) })
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+
+	alice.say(t, "one")
+	alice.say(t, "two")
+	alice.say(t, "three")
+	bob.expect(t, "alice's first message", messageFrom("alice", "one"))
+	bob.expect(t, "alice's second message", messageFrom("alice", "two"))
+	res := alice.expect(t, "a rate limit warning", serverError)
+	require.Contains(t, res.GetServerError().Message, "too fast")
+
+	// 大きすぎる発言は頻度に関わらず拒否する
+	bob.say(t, strings.Repeat("x", 100))
+	res = bob.expect(t, "a size warning", serverError)
+	require.Contains(t, res.GetServerError().Message, "too large")
+
+	bob.say(t, "short")
+	alice.expect(t, "bob's message", messageFrom("bob", "short"))
+
+	m := ts.Prometheus.rateLimits
+	require.Equal(t, 1.0, testutil.ToFloat64(m.WithLabelValues(limitMessages)))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.WithLabelValues(limitBytes)))
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	ts := startServer(t, func(s *server) { s.Limits = newRateLimits(0, 0, 0, 2) })
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := chat.NewChatClient(ts.conn(t))
+
+	// 失敗したLoginも試行として数え、上限を超えると正しいパスワードでも拒否する
+	_, err := c.Login(ctx, &chat.LoginRequest{Name: "mallory", Password: "wrong"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.Login(ctx, &chat.LoginRequest{Name: "mallory", Password: "guess"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.Login(ctx, &chat.LoginRequest{Name: "mallory", Password: testPassword})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	require.Equal(t, 1.0, testutil.ToFloat64(ts.Prometheus.rateLimits.WithLabelValues(limitLogins)))
+}
+
+func TestTypingRateLimit(t *testing.T) {
+	ts := startServer(t)
+	bob := ts.startClient(t, "bob")
+
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	res, err := cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	require.NoError(t, err)
+	stream, err := cc.Stream(outgoingContext(ctx, res.Token, 0))
+	require.NoError(t, err)
+
+	// 入力中の通知を大量に送っても、制限を超えた分はブロードキャストされない
+	for i := 0; i < 50; i++ {
+		require.NoError(t, stream.Send(typingRequest(i%2 == 0, defaultRoom)))
+	}
+	require.NoError(t, stream.Send(&chat.StreamRequest{Message: "done", Room: defaultRoom}))
+
+	n := 0
+	bob.expect(t, "alice's message", func(res *chat.StreamResponse) bool {
+		if isTyping(res) {
+			n++
+		}
+		return messageFrom("alice", "done")(res)
+	})
+	require.GreaterOrEqual(t, n, defaultTypingBurst)
+	require.Less(t, n, 2*defaultTypingBurst)
+	require.Equal(t, float64(50-n), testutil.ToFloat64(ts.Prometheus.rateLimits.WithLabelValues(limitTyping)))
+}
+
+func TestLoginIPFromTrustedProxy(t *testing.T) {
+	s := Server("bufnet", testPassword)
+	s.Limits = newRateLimits(0, 0, 0, 1)
+	var err error
+	s.TrustedProxies, err = parseTrustedProxies("10.0.0.1, 192.168.0.0/16")
+	require.NoError(t, err)
+	_, err = parseTrustedProxies("10.0.0.1,bridge")
+	require.Error(t, err)
+
+	login := func(from, forwarded string) context.Context {
+		addr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(from, "5000"))
+		require.NoError(t, err)
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		if forwarded != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(clientIPHeader, forwarded))
+		}
+		return ctx
+	}
+
+	// 信頼するブリッジからはブラウザのIPアドレスで数え、それ以外からは接続元のアドレスで数える
+	require.Equal(t, "203.0.113.5", s.loginIP(login("10.0.0.1", "203.0.113.5")))
+	require.Equal(t, "203.0.113.5", s.loginIP(login("192.168.3.4", "203.0.113.5")))
+	require.Equal(t, "10.0.0.2", s.loginIP(login("10.0.0.2", "203.0.113.5")))
+	require.Equal(t, "10.0.0.1", s.loginIP(login("10.0.0.1", "not an ip")))
+	require.Equal(t, "10.0.0.1", s.loginIP(login("10.0.0.1", "")))
+
+	// 同じブリッジを経由していても、ブラウザごとに別の制限になる
+	require.NoError(t, s.checkLogin(login("10.0.0.1", "203.0.113.5")))
+	require.NoError(t, s.checkLogin(login("10.0.0.1", "203.0.113.6")))
+	require.Equal(t, codes.ResourceExhausted, status.Code(s.checkLogin(login("10.0.0.1", "203.0.113.5"))))
+}
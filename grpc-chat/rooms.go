@@ -0,0 +1,198 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ログインしたクライアントが最初に参加するルーム
+const defaultRoom = "lobby"
+
+// ルームへの参加を処理するメソッド
+func (s *server) JoinRoom(ctx context.Context, req *chat.JoinRoomRequest) (*chat.JoinRoomResponse, error) {
+	payload := sessionFrom(ctx)
+	tkn, name := payload.ID, payload.Username
+
+	room := normalizeRoom(req.Room)
+	if !s.joinRoom(tkn, room) {
+		return nil, status.Errorf(codes.AlreadyExists, "already a member of room %q", room)
+	}
+
+	s.Log.Info("joined room", "token", tkn, "name", name, "room", room)
+
+	// 参加したルームの直近のメッセージを再送
+	if s.Replay > 0 {
+		history, err := s.Store.History(roomFilter{room: room}, time.Time{}, s.Replay)
+		if err != nil {
+			s.Log.Error("failed to load history", "token", tkn, "room", room, "err", err)
+		}
+		for _, res := range history {
+			s.sendTo(tkn, res)
+		}
+	}
+
+	return new(chat.JoinRoomResponse), nil
+}
+
+// ルームからの退出を処理するメソッド、デフォルトのルームからは退出できない
+func (s *server) LeaveRoom(ctx context.Context, req *chat.LeaveRoomRequest) (*chat.LeaveRoomResponse, error) {
+	payload := sessionFrom(ctx)
+	tkn, name := payload.ID, payload.Username
+
+	room := normalizeRoom(req.Room)
+	if room == defaultRoom {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot leave the default room %q", room)
+	}
+	if !s.leaveRoom(tkn, room) {
+		return nil, status.Errorf(codes.NotFound, "not a member of room %q", room)
+	}
+
+	s.Log.Info("left room", "token", tkn, "name", name, "room", room)
+
+	return new(chat.LeaveRoomResponse), nil
+}
+
+// 存在するルームとその参加者の一覧を返すメソッド
+func (s *server) ListRooms(_ context.Context, _ *chat.ListRoomsRequest) (*chat.ListRoomsResponse, error) {
+	s.roomsMtx.RLock()
+	rooms := make([]*chat.ListRoomsResponse_Room, 0, len(s.Rooms))
+	for room, members := range s.Rooms {
+		r := &chat.ListRoomsResponse_Room{Name: room, Topic: s.Topics[room]}
+		for tkn := range members {
+			if name, ok := s.getName(tkn); ok {
+				r.Members = append(r.Members, name)
+			}
+		}
+		sort.Strings(r.Members)
+		rooms = append(rooms, r)
+	}
+	s.roomsMtx.RUnlock()
+
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].Name < rooms[j].Name })
+
+	return &chat.ListRoomsResponse{Rooms: rooms}, nil
+}
+
+// ルーム名の正規化、先頭の"#"と前後の空白を取り除き、空のときはデフォルトのルームとする
+func normalizeRoom(room string) string {
+	room = strings.TrimPrefix(strings.TrimSpace(room), "#")
+	if room == "" {
+		return defaultRoom
+	}
+	return room
+}
+
+// クライアントをルームに追加するメソッド、既に参加していた場合はfalseを返す
+func (s *server) joinRoom(tkn, room string) bool {
+	s.roomsMtx.Lock()
+	defer s.roomsMtx.Unlock()
+
+	members, ok := s.Rooms[room]
+	if !ok {
+		members = make(map[string]struct{})
+		s.Rooms[room] = members
+	}
+	if _, ok := members[tkn]; ok {
+		return false
+	}
+	members[tkn] = struct{}{}
+	return true
+}
+
+// クライアントをルームから外すメソッド、参加者がいなくなったルームは削除する
+func (s *server) leaveRoom(tkn, room string) bool {
+	s.roomsMtx.Lock()
+	defer s.roomsMtx.Unlock()
+
+	members, ok := s.Rooms[room]
+	if !ok {
+		return false
+	}
+	if _, ok := members[tkn]; !ok {
+		return false
+	}
+	delete(members, tkn)
+	if len(members) == 0 && room != defaultRoom {
+		delete(s.Rooms, room)
+	}
+	return true
+}
+
+// ログアウト時にクライアントを全てのルームから外すメソッド
+func (s *server) leaveAllRooms(tkn string) {
+	s.roomsMtx.Lock()
+	defer s.roomsMtx.Unlock()
+
+	for room, members := range s.Rooms {
+		delete(members, tkn)
+		if len(members) == 0 && room != defaultRoom {
+			delete(s.Rooms, room)
+		}
+	}
+}
+
+// クライアントがルームに参加しているかを確認するメソッド
+func (s *server) inRoom(room, tkn string) bool {
+	s.roomsMtx.RLock()
+	defer s.roomsMtx.RUnlock()
+
+	_, ok := s.Rooms[room][tkn]
+	return ok
+}
+
+// クライアントが参加しているルームの集合を返すメソッド
+func (s *server) roomsOf(tkn string) map[string]struct{} {
+	s.roomsMtx.RLock()
+	defer s.roomsMtx.RUnlock()
+
+	rooms := make(map[string]struct{})
+	for room, members := range s.Rooms {
+		if _, ok := members[tkn]; ok {
+			rooms[room] = struct{}{}
+		}
+	}
+	return rooms
+}
+
+// ブロードキャストされたイベントをクライアントに配信するべきかを判定するメソッド、ルーム宛てやダイレクトメッセージでないイベントは全員に配信
+func (s *server) shouldReceive(tkn string, res *chat.StreamResponse) bool {
+	switch evt := res.Event.(type) {
+	case *chat.StreamResponse_ClientMessage:
+		if evt.ClientMessage.Room != "" {
+			return s.inRoom(evt.ClientMessage.Room, tkn)
+		}
+	case *chat.StreamResponse_MessageEdited, *chat.StreamResponse_MessageDeleted, *chat.StreamResponse_MessageReaction,
+		*chat.StreamResponse_FileAttachment:
+		room, _ := eventRoom(res)
+		return s.inRoom(room, tkn)
+	case *chat.StreamResponse_Typing:
+		return s.inRoom(evt.Typing.Room, tkn)
+	case *chat.StreamResponse_StoppedTyping:
+		return s.inRoom(evt.StoppedTyping.Room, tkn)
+	case *chat.StreamResponse_ModerationAction:
+		if evt.ModerationAction.Action == chat.StreamResponse_Moderation_TOPIC {
+			return s.inRoom(evt.ModerationAction.Room, tkn)
+		}
+	case *chat.StreamResponse_ServerAnnouncement:
+		// ルームを指定したお知らせはそのルームの参加者にのみ配信
+		if evt.ServerAnnouncement.Room != "" {
+			return s.inRoom(evt.ServerAnnouncement.Room, tkn)
+		}
+	case *chat.StreamResponse_ClientKicked:
+		// キックされたことは対象のユーザにのみ知らせる
+		name, ok := s.getName(tkn)
+		return ok && name == evt.ClientKicked.Name
+	case *chat.StreamResponse_DirectMessage:
+		// ダイレクトメッセージは送信者と宛先のユーザにのみ配信
+		name, ok := s.getName(tkn)
+		return ok && (name == evt.DirectMessage.From || name == evt.DirectMessage.To)
+	}
+	return true
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	chat "grpc-chat/protos"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// 検索のインデックスに保持するメッセージの最大数、超えた分は古いものから外す
+	defaultSearchSize = 10000
+	// Search RPCの1ページの件数のデフォルト値と最大値
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// 履歴のメッセージの転置インデックス、語からその語を含むメッセージを引く
+// 漢字と仮名は分かち書きされないので1文字を1語とし、複数の文字からなる語は並びが一致するものを探す
+type searchIndex struct {
+	size int
+	// メッセージIDをキーとしてインデックスに登録したメッセージを保持、orderは古い順のID
+	docs  map[string]*indexedMessage
+	order []string
+	// 語をキーとして、その語を含むメッセージIDの集合を保持
+	postings map[string]map[string]struct{}
+	// postingsの語を辞書順に並べたもの、前方一致の検索に使う
+	terms []string
+	mtx   sync.RWMutex
+}
+
+// インデックスに登録した1件のメッセージ
+type indexedMessage struct {
+	ID, Room, Name, Text string
+	Time                 time.Time
+	Seq                  uint64
+	// 本文を分割した語、語の並びはフレーズの照合に使う
+	tokens []string
+}
+
+// 検索の1つの条件、tokensの並びを含むメッセージに一致する、prefixのときは最後の語を前方一致で比べる
+type searchClause struct {
+	tokens []string
+	prefix bool
+}
+
+// 検索の条件、roomとfromが空のときやstartとendがゼロ値のときはその条件で絞り込まない
+type searchQuery struct {
+	clauses    []searchClause
+	room, from string
+	start, end time.Time
+	// 検索したユーザが参加しているルーム、これ以外のルームの発言は返さない、nilのときは絞り込まない
+	rooms map[string]struct{}
+}
+
+// 最大size件のメッセージを保持するsearchIndexを生成する関数
+func newSearchIndex(size int) *searchIndex {
+	return &searchIndex{
+		size:     size,
+		docs:     make(map[string]*indexedMessage),
+		postings: make(map[string]map[string]struct{}),
+	}
+}
+
+// 履歴に保存するイベントをインデックスに反映するメソッド、編集されたメッセージは新しい本文で登録し直し、削除されたメッセージは外す
+func (x *searchIndex) update(res *chat.StreamResponse) {
+	x.mtx.Lock()
+	defer x.mtx.Unlock()
+
+	switch evt := res.Event.(type) {
+	case *chat.StreamResponse_ClientMessage:
+		msg := evt.ClientMessage
+		if msg.Id == "" {
+			return
+		}
+		x.remove(msg.Id)
+		x.add(&indexedMessage{ID: msg.Id, Room: msg.Room, Name: msg.Name, Text: msg.Message, Time: res.Timestamp.AsTime(), Seq: res.Sequence})
+		x.order = append(x.order, msg.Id)
+		x.evict()
+	case *chat.StreamResponse_MessageEdited:
+		if doc, ok := x.docs[evt.MessageEdited.Id]; ok {
+			x.remove(doc.ID)
+			doc.Text = evt.MessageEdited.Message
+			x.add(doc)
+		}
+	case *chat.StreamResponse_MessageDeleted:
+		x.remove(evt.MessageDeleted.Id)
+	}
+}
+
+// メッセージの語をインデックスに登録するメソッド
+func (x *searchIndex) add(doc *indexedMessage) {
+	doc.tokens = tokenize(doc.Text)
+	x.docs[doc.ID] = doc
+	for _, t := range doc.tokens {
+		ids, ok := x.postings[t]
+		if !ok {
+			ids = make(map[string]struct{})
+			x.postings[t] = ids
+			i := sort.SearchStrings(x.terms, t)
+			x.terms = append(x.terms, "")
+			copy(x.terms[i+1:], x.terms[i:])
+			x.terms[i] = t
+		}
+		ids[doc.ID] = struct{}{}
+	}
+}
+
+// メッセージをインデックスから外すメソッド、どのメッセージにも含まれなくなった語は削除する
+func (x *searchIndex) remove(id string) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	delete(x.docs, id)
+	for _, t := range doc.tokens {
+		ids, ok := x.postings[t]
+		if !ok {
+			continue
+		}
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(x.postings, t)
+			i := sort.SearchStrings(x.terms, t)
+			x.terms = append(x.terms[:i], x.terms[i+1:]...)
+		}
+	}
+}
+
+// 保持する件数を超えた古いメッセージを外すメソッド
+// 削除されたメッセージのIDもorderに残るので、orderが長くなりすぎたときは詰め直す
+func (x *searchIndex) evict() {
+	for len(x.docs) > x.size {
+		x.remove(x.order[0])
+		x.order = x.order[1:]
+	}
+	if len(x.order) > 2*x.size {
+		order := make([]string, 0, len(x.docs))
+		for _, id := range x.order {
+			if _, ok := x.docs[id]; ok {
+				order = append(order, id)
+			}
+		}
+		x.order = order
+	}
+}
+
+// 条件に一致するメッセージを新しいものから最大limit件返すメソッド、beforeが0より大きいときはシーケンス番号がそれより小さいものだけを返す
+// 一致したメッセージの総数と、続きがあるときは最後に返したメッセージのシーケンス番号も返す
+func (x *searchIndex) search(q *searchQuery, limit int, before uint64) (hits []*indexedMessage, total int, next uint64) {
+	x.mtx.RLock()
+	defer x.mtx.RUnlock()
+
+	var matched []*indexedMessage
+	for id := range x.candidates(q.clauses) {
+		doc := x.docs[id]
+		if q.matches(doc) {
+			matched = append(matched, doc)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Seq != matched[j].Seq {
+			return matched[i].Seq > matched[j].Seq
+		}
+		return matched[i].ID > matched[j].ID
+	})
+
+	total = len(matched)
+	if before > 0 {
+		matched = matched[sort.Search(len(matched), func(i int) bool { return matched[i].Seq < before }):]
+	}
+	if len(matched) > limit {
+		matched = matched[:limit]
+		next = matched[limit-1].Seq
+	}
+	// 呼び出し元がロックの外で読めるようにコピーを返す
+	hits = make([]*indexedMessage, len(matched))
+	for i, doc := range matched {
+		c := *doc
+		hits[i] = &c
+	}
+	return hits, total, next
+}
+
+// 条件に一致しうるメッセージIDの集合を返すメソッド、含むメッセージが最も少ない語で絞り込む
+func (x *searchIndex) candidates(clauses []searchClause) map[string]struct{} {
+	var (
+		best  map[string]struct{}
+		found bool
+	)
+	for _, c := range clauses {
+		for i, t := range c.tokens {
+			var ids map[string]struct{}
+			if c.prefix && i == len(c.tokens)-1 {
+				ids = x.prefixed(t)
+			} else {
+				ids = x.postings[t]
+			}
+			if !found || len(ids) < len(best) {
+				best, found = ids, true
+			}
+		}
+	}
+	return best
+}
+
+// prefixで始まる語を含むメッセージIDの集合を返すメソッド
+func (x *searchIndex) prefixed(prefix string) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for i := sort.SearchStrings(x.terms, prefix); i < len(x.terms) && strings.HasPrefix(x.terms[i], prefix); i++ {
+		for id := range x.postings[x.terms[i]] {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// メッセージが全ての条件に一致するかを判定するメソッド
+func (q *searchQuery) matches(doc *indexedMessage) bool {
+	_, member := q.rooms[doc.Room]
+	switch {
+	case q.rooms != nil && doc.Room != "" && !member:
+		return false
+	case q.room != "" && doc.Room != q.room:
+		return false
+	case q.from != "" && doc.Name != q.from:
+		return false
+	case !q.start.IsZero() && doc.Time.Before(q.start):
+		return false
+	case !q.end.IsZero() && !doc.Time.Before(q.end):
+		return false
+	}
+	for _, c := range q.clauses {
+		if !c.matches(doc.tokens) {
+			return false
+		}
+	}
+	return true
+}
+
+// 語の並びがtokensのどこかに現れるかを判定するメソッド
+func (c searchClause) matches(tokens []string) bool {
+	last := len(c.tokens) - 1
+	for p := 0; p+last < len(tokens); p++ {
+		ok := true
+		for k, t := range c.tokens {
+			if got := tokens[p+k]; got != t && !(c.prefix && k == last && strings.HasPrefix(got, t)) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 本文を小文字の語に分割する関数、文字と数字以外は区切りとして扱い、漢字と仮名は1文字ずつ分ける
+func tokenize(text string) []string {
+	var (
+		tokens []string
+		word   strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// 検索語を条件に分解する関数、"..."で囲んだ部分は語の並び、末尾が*の語は前方一致の条件にする
+// 区切り文字を含む語(e-mailなど)や漢字・仮名の語は、分割した語の並びとして扱う
+func parseQuery(query string) []searchClause {
+	var clauses []searchClause
+	for rest := strings.TrimSpace(query); rest != ""; rest = strings.TrimSpace(rest) {
+		var (
+			term   string
+			phrase bool
+		)
+		if strings.HasPrefix(rest, `"`) {
+			// 閉じていない"は最後までをフレーズとする
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			term, rest, phrase = rest[1:end+1], rest[min(end+2, len(rest)):], true
+		} else {
+			end := strings.IndexAny(rest, " \t\"")
+			if end < 0 {
+				end = len(rest)
+			}
+			term, rest = rest[:end], rest[end:]
+		}
+
+		prefix := !phrase && strings.HasSuffix(term, "*")
+		if tokens := tokenize(term); len(tokens) > 0 {
+			clauses = append(clauses, searchClause{tokens: tokens, prefix: prefix})
+		}
+	}
+	return clauses
+}
+
+// 履歴のメッセージを全文検索するメソッド、結果はシーケンス番号の新しい順に並べてページに分けて返す
+// 次のページの位置は最後に返したメッセージのシーケンス番号で表すので、ページをめくる間に新しいメッセージが届いても結果はずれない
+// ストリームと同じく、参加していないルームの発言は検索できない
+func (s *server) Search(ctx context.Context, req *chat.SearchRequest) (*chat.SearchResponse, error) {
+	payload := sessionFrom(ctx)
+	q := &searchQuery{clauses: parseQuery(req.Query), from: req.FromUser, rooms: s.roomsOf(payload.ID)}
+	if len(q.clauses) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "query must contain at least one word")
+	}
+	if req.Room != "" {
+		q.room = normalizeRoom(req.Room)
+		if _, ok := q.rooms[q.room]; !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "not a member of room %q", q.room)
+		}
+	}
+	if r := req.TimeRange; r != nil {
+		if r.Start != nil {
+			q.start = r.Start.AsTime()
+		}
+		if r.End != nil {
+			q.end = r.End.AsTime()
+		}
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	} else if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	var before uint64
+	if req.PageToken != "" {
+		var err error
+		if before, err = strconv.ParseUint(req.PageToken, 10, 64); err != nil || before == 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	hits, total, next := s.Index.search(q, limit, before)
+	res := &chat.SearchResponse{Total: int32(total)}
+	if next > 0 {
+		res.NextPageToken = strconv.FormatUint(next, 10)
+	}
+	for _, doc := range hits {
+		res.Hits = append(res.Hits, &chat.SearchResponse_Hit{
+			Id:        doc.ID,
+			Room:      doc.Room,
+			Name:      doc.Name,
+			Message:   doc.Text,
+			Timestamp: timestamppb.New(doc.Time),
+			Sequence:  doc.Seq,
+		})
+	}
+	return res, nil
+}
+
+// "/search"の引数から検索のリクエストを生成する関数
+// "from:<name>"、"in:<room>"、"after:<YYYY-MM-DD>"、"before:<YYYY-MM-DD>"で絞り込み、残りを検索語にする
+func searchRequest(arg string) (*chat.SearchRequest, error) {
+	req := new(chat.SearchRequest)
+	var (
+		words []string
+		r     chat.TimeRange
+	)
+	for _, f := range strings.Fields(arg) {
+		key, val, _ := strings.Cut(f, ":")
+		switch {
+		case key == "from" && val != "":
+			req.FromUser = val
+		case key == "in" && val != "":
+			req.Room = normalizeRoom(val)
+		case (key == "after" || key == "before") && val != "":
+			day, err := time.ParseInLocation(time.DateOnly, val, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date %q, use YYYY-MM-DD", val)
+			}
+			if key == "after" {
+				r.Start = timestamppb.New(day.AddDate(0, 0, 1))
+			} else {
+				r.End = timestamppb.New(day)
+			}
+		default:
+			words = append(words, f)
+		}
+	}
+	if r.Start != nil || r.End != nil {
+		req.TimeRange = &r
+	}
+	req.Query = strings.Join(words, " ")
+	return req, nil
+}
+
+// 検索を実行して結果を表示するメソッド、続きがあるときは次のページのリクエストを覚えておき"/search"だけで表示する
+func (c *client) search(ctx context.Context, req *chat.SearchRequest) {
+	req.Token = c.token()
+	res, err := c.ChatClient.Search(ctx, req)
+	if err != nil {
+		ClientNotef(time.Now(), "failed to search: %v", err)
+		return
+	}
+
+	for _, h := range res.Hits {
+		MessageLog(h.Timestamp.AsTime().In(time.Local), roomName(h.Room, h.Name), h.Message+"  <"+h.Id+">")
+	}
+	c.nextSearch = nil
+	if res.NextPageToken != "" {
+		req.PageToken = res.NextPageToken
+		c.nextSearch = req
+		ClientNotef(time.Now(), "-- %d result(s) in total, type /search for more --", res.Total)
+		return
+	}
+	ClientNotef(time.Now(), "-- %d result(s) in total --", res.Total)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// インデックスに登録するメッセージのイベントを生成する関数
+func indexedEvent(seq uint64, at time.Time, id, room, name, text string) *chat.StreamResponse {
+	return &chat.StreamResponse{
+		Timestamp: timestamppb.New(at),
+		Sequence:  seq,
+		Event: &chat.StreamResponse_ClientMessage{
+			ClientMessage: &chat.StreamResponse_Message{Id: id, Room: room, Name: name, Message: text},
+		},
+	}
+}
+
+// 検索結果のメッセージIDを返す関数
+func hitIDs(hits []*indexedMessage) []string {
+	ids := make([]string, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	require.Equal(t, []string{"deploy", "v2", "3", "is", "done"}, tokenize("Deploy v2.3 is DONE!"))
+	require.Equal(t, []string{"東", "京", "で", "deploy"}, tokenize("東京でdeploy"))
+	require.Equal(t, []searchClause{
+		{tokens: []string{"build", "failed"}},
+		{tokens: []string{"deplo"}, prefix: true},
+		{tokens: []string{"e", "mail"}},
+		{tokens: []string{"東", "京"}},
+	}, parseQuery(`"build failed" deplo* e-mail 東京`))
+	require.Empty(t, parseQuery(` "" * -- `))
+}
+
+func TestSearchIndex(t *testing.T) {
+	x := newSearchIndex(4)
+	base := time.Date(2024, 3, 19, 12, 0, 0, 0, time.UTC)
+	x.update(indexedEvent(1, base, "a", "lobby", "alice", "the build failed again"))
+	x.update(indexedEvent(2, base.Add(time.Hour), "b", "dev", "bob", "build passed, deploying now"))
+	x.update(indexedEvent(3, base.Add(2*time.Hour), "c", "dev", "alice", "failed build on main"))
+	x.update(indexedEvent(4, base.Add(3*time.Hour), "d", "lobby", "carol", "東京で会議"))
+
+	search := func(q *searchQuery) []string {
+		hits, _, _ := x.search(q, 10, 0)
+		return hitIDs(hits)
+	}
+	query := func(s string) *searchQuery { return &searchQuery{clauses: parseQuery(s)} }
+
+	// 新しいものから順に返す
+	require.Equal(t, []string{"c", "b", "a"}, search(query("build")))
+	require.Equal(t, []string{"c", "a"}, search(query("build failed")))
+	require.Equal(t, []string{"a"}, search(query(`"build failed"`)))
+	require.Equal(t, []string{"b"}, search(query("deploy*")))
+	require.Empty(t, search(query("deploy")))
+	require.Equal(t, []string{"d"}, search(query("東京")))
+	require.Empty(t, search(query("京東")))
+
+	// ルーム、発言者、期間で絞り込む
+	q := query("build")
+	q.room = "dev"
+	require.Equal(t, []string{"c", "b"}, search(q))
+	q.from = "alice"
+	require.Equal(t, []string{"c"}, search(q))
+	q = query("build")
+	q.start, q.end = base.Add(30*time.Minute), base.Add(2*time.Hour)
+	require.Equal(t, []string{"b"}, search(q))
+
+	// ページに分けて返す
+	hits, total, next := x.search(query("build"), 2, 0)
+	require.Equal(t, []string{"c", "b"}, hitIDs(hits))
+	require.Equal(t, 3, total)
+	require.Equal(t, uint64(2), next)
+	hits, _, next = x.search(query("build"), 2, next)
+	require.Equal(t, []string{"a"}, hitIDs(hits))
+	require.Zero(t, next)
+
+	// 編集と削除を反映する
+	x.update(&chat.StreamResponse{Event: &chat.StreamResponse_MessageEdited{MessageEdited: &chat.StreamResponse_Edited{Id: "a", Message: "all good now"}}})
+	x.update(&chat.StreamResponse{Event: &chat.StreamResponse_MessageDeleted{MessageDeleted: &chat.StreamResponse_Deleted{Id: "c"}}})
+	require.Equal(t, []string{"b"}, search(query("build")))
+	require.Equal(t, []string{"a"}, search(query("good")))
+	require.Empty(t, search(query("fail*")))
+
+	// 保持する件数を超えると古いものから外す
+	x.update(indexedEvent(5, base.Add(4*time.Hour), "e", "lobby", "bob", "good luck"))
+	x.update(indexedEvent(6, base.Add(5*time.Hour), "f", "lobby", "bob", "good night"))
+	require.Equal(t, []string{"f", "e"}, search(query("good")))
+	require.Len(t, x.docs, 4)
+}
+
+func TestSearch(t *testing.T) {
+	ts := startServer(t)
+	alice := ts.startClient(t, "alice")
+	alice.say(t, "release notes are ready")
+	alice.say(t, "/join dev")
+	alice.say(t, "release 1.2 tagged")
+	alice.say(t, "the release went out")
+	alice.expect(t, "her last message", messageFrom("alice", "the release went out"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = outgoingContext(ctx, alice.token(), 0)
+	c := chat.NewChatClient(ts.conn(t))
+
+	res, err := c.Search(ctx, &chat.SearchRequest{Query: "release", Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), res.Total)
+	require.Len(t, res.Hits, 2)
+	require.Equal(t, "the release went out", res.Hits[0].Message)
+	require.NotEmpty(t, res.Hits[0].Id)
+	require.NotNil(t, res.Hits[0].Timestamp)
+	require.NotEmpty(t, res.NextPageToken)
+
+	res, err = c.Search(ctx, &chat.SearchRequest{Query: "release", Limit: 2, PageToken: res.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, res.Hits, 1)
+	require.Equal(t, "release notes are ready", res.Hits[0].Message)
+	require.Empty(t, res.NextPageToken)
+
+	res, err = c.Search(ctx, &chat.SearchRequest{Query: `"release notes"`, Room: "#lobby", FromUser: "alice"})
+	require.NoError(t, err)
+	require.Len(t, res.Hits, 1)
+	res, err = c.Search(ctx, &chat.SearchRequest{Query: "release", TimeRange: &chat.TimeRange{End: timestamppb.New(time.Now().Add(-time.Hour))}})
+	require.NoError(t, err)
+	require.Empty(t, res.Hits)
+
+	_, err = c.Search(ctx, &chat.SearchRequest{Query: " * "})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.Search(ctx, &chat.SearchRequest{Query: "release", PageToken: "next"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// 参加していないルームの発言は検索結果に含めず、ルームを指定しても検索できない
+	bob := ts.startClient(t, "bob")
+	ctx, cancel = context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = outgoingContext(ctx, bob.token(), 0)
+	res, err = c.Search(ctx, &chat.SearchRequest{Query: "release"})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), res.Total)
+	require.Equal(t, "release notes are ready", res.Hits[0].Message)
+	_, err = c.Search(ctx, &chat.SearchRequest{Query: "release", Room: "dev"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestSearchRequest(t *testing.T) {
+	req, err := searchRequest(`from:alice in:#dev after:2024-03-01 before:2024-03-10 "build failed" deploy*`)
+	require.NoError(t, err)
+	require.Equal(t, "alice", req.FromUser)
+	require.Equal(t, "dev", req.Room)
+	require.Equal(t, `"build failed" deploy*`, req.Query)
+	require.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local), req.TimeRange.Start.AsTime().In(time.Local))
+	require.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local), req.TimeRange.End.AsTime().In(time.Local))
+
+	_, err = searchRequest("after:yesterday build")
+	require.Error(t, err)
+}
@@ -2,39 +2,113 @@ package main
 
 import (
 	// "context"
-	"crypto/rand"
-	"fmt"
 	"io"
+	"log/slog"
 	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
-	chat "github.com/rodaine/grpc-chat/protos"
+	"grpc-chat/e2e"
+	chat "grpc-chat/protos"
+	"grpc-chat/token"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-const tokenHeader = "x-chat-token"
-
 // gRPCサーバによる通信処理において、それらの処理内容(関連する各種データ)を運搬する役割を担う；サーバがクライアントから受けとる各種データを扱いそれに基づいて適切な処理を行うためのメソッドを持つ
 type server struct {
 	// サーバのアドレス(ホスト名,IPアドレス)＋クライアントが接続するときに必要なPW
 	Host, Password string
+	// 接続を受け付けるリスナー、nilのときはHostでTCPのポートを開く
+	Listener net.Listener
+	// ユーザごとのアカウント、nilのときは全員が共通のPasswordでログインする
+	Users *userRegistry
 	// サーバから全クライアントへブロードキャストするメッセージを一時的に保持するためのチャネル
 	Broadcast chan *chat.StreamResponse
+	// ブロードキャストされたイベントを他のレプリカと共有するためのブローカー
+	Broker Broker
+	// これまでに配信した最大のシーケンス番号
+	seq atomic.Uint64
 
 	ClientNames map[string]string
 	// トークンをキーとしてそのユーザのメッセージストリームを保持
-	ClientStreams map[string]chan *chat.StreamResponse
-
-	// ClientStreamsマップとClientNamesマップを操作する際に同時に複数の操作が行われることを防ぐための読み書きロック機能を提供するメンバ
-	namesMtx, streamsMtx sync.RWMutex
+	ClientStreams map[string]*clientStream
+	// ルーム名をキーとして、そのルームに参加しているクライアントのトークンの集合を保持
+	Rooms map[string]map[string]struct{}
+	// トークンをキーとしてそのセッションのログイン時刻と最後に操作した時刻を保持
+	Presence map[string]*presence
+	// ブロードキャストされたメッセージの履歴の保存先
+	Store MessageStore
+	// Stream接続時やルーム参加時に再送する履歴の件数
+	Replay int
+	// /kickなどのコマンドを使えるモデレータのユーザ名
+	Moderators map[string]bool
+	// ログインを禁止されたユーザ
+	Bans *banList
+	// 発言を禁止されたユーザ名とその期限、ゼロ値のときは無期限
+	Mutes map[string]time.Time
+	// ルーム名をキーとしてモデレータが設定した話題を保持
+	Topics map[string]string
+	// メッセージIDをキーとして直近のメッセージの送信者などを保持、messageOrderは古い順のID
+	Messages     map[string]*messageInfo
+	messageOrder []string
+	// 履歴のメッセージの全文検索のインデックス
+	Index *searchIndex
+	// 添付ファイルの保存先、nilのときは添付を受け付けない
+	Blobs *blobStore
+	// セッショントークンの発行と検証を行う
+	Tokens token.Maker
+	// 発行するトークンの有効期間
+	TokenTTL time.Duration
+	// ログアウトしたセッションIDとそのトークンの有効期限、期限までは同じトークンでの再接続を拒否する
+	Revoked map[string]time.Time
+	// メソッドごとの呼び出しの集計
+	Metrics *rpcMetrics
+	// Prometheusに公開する指標と、/metricsを待ち受けるアドレス、空のときは公開しない
+	Prometheus  *promMetrics
+	MetricsAddr string
+	// TLSの認証情報、nilのときは平文で通信する
+	Creds credentials.TransportCredentials
+	// 発言の頻度と大きさ、IPアドレスごとのLoginの頻度の制限
+	Limits *rateLimits
+	// 送信元のIPアドレスを中継してくるHTTPブリッジのアドレス、それ以外から届いた送信元のIPアドレスは無視する
+	TrustedProxies []*net.IPNet
+	// クライアントのストリームが一杯になったときの振る舞いと、blockのときに待つ時間
+	Backpressure backpressurePolicy
+	BlockTimeout time.Duration
+	// 診断用のログの出力先
+	Log *slog.Logger
+	// シャットダウン時にストリームを送り終えるまで待つ時間
+	ShutdownGrace time.Duration
+	// シャットダウン時にセッションの状態を保存し、起動時に読み込むファイル、空のときは保存しない
+	SessionsFile string
+	// 前回のシャットダウン時に保存したセッションのうち、まだ再接続していないもの
+	resumable map[string]*savedSession
+	// gRPCのヘルスチェックの状態、ドレイン中はNOT_SERVINGになる
+	Health *health.Server
+
+	// Adminサービスの統計、起動した時刻と配信したメッセージ・破棄したメッセージの件数
+	started                time.Time
+	msgTotal, droppedTotal atomic.Uint64
+	msgRate                rateMeter
+	// 新しいログインの受付を止めているか
+	draining atomic.Bool
+
+	// ClientStreamsマップとClientNamesマップ、Roomsマップを操作する際に同時に複数の操作が行われることを防ぐための読み書きロック機能を提供するメンバ
+	namesMtx, streamsMtx, roomsMtx                            sync.RWMutex
+	revokedMtx, presenceMtx, mutesMtx, messagesMtx, resumeMtx sync.Mutex
 	chat.UnimplementedChatServer
 }
 
@@ -69,33 +143,121 @@ type server struct {
 
 // サーバ構造体のインスタンスを生成するメソッド
 func Server(host, pass string) *server {
-	return &server{
+	// ランダムな鍵は最小長を満たすため生成に失敗しない
+	tokens, _ := token.NewHMACMaker(randomSecret())
+
+	s := &server{
 		Host:     host,
 		Password: pass,
 		// 1000個の*chat.StreamResponse型のメッセージをバッファに格納できるチャネル、なぜポインタ型を指定しているか：メッセージ情報を格納する構造体を実体として渡そうとするとコピー処理が必要で時間・リソースコストが高くなるから→データサイズが大きい時、頻繁なデータのやり取りのときはポインタを介してデータを参照するのが好まれる
 		Broadcast:     make(chan *chat.StreamResponse, 1000),
+		Broker:        newLocalBroker(),
 		ClientNames:   make(map[string]string),
-		ClientStreams: make(map[string]chan *chat.StreamResponse),
+		ClientStreams: make(map[string]*clientStream),
+		Rooms:         make(map[string]map[string]struct{}),
+		Presence:      make(map[string]*presence),
+		Store:         newRingStore(defaultHistorySize),
+		Index:         newSearchIndex(defaultSearchSize),
+		Replay:        defaultReplaySize,
+		Moderators:    make(map[string]bool),
+		Bans:          newBanList(),
+		Mutes:         make(map[string]time.Time),
+		Topics:        make(map[string]string),
+		Messages:      make(map[string]*messageInfo),
+		Tokens:        tokens,
+		TokenTTL:      defaultTokenTTL,
+		Revoked:       make(map[string]time.Time),
+		Metrics:       newRPCMetrics(),
+		Limits:        newRateLimits(defaultMessageRate, defaultMessageBurst, defaultMaxMessageBytes, defaultLoginsPerMinute),
+		Backpressure:  dropNewest,
+		BlockTimeout:  100 * time.Millisecond,
+		Health:        health.NewServer(),
+		Log:           logger.With("component", "server"),
+		ShutdownGrace: defaultShutdownGrace,
+		resumable:     make(map[string]*savedSession),
 	}
+	s.Prometheus = newPromMetrics(s)
+	return s
 }
 
 func (s *server) Run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	defer s.Store.Close()
+	defer s.Broker.Close()
 
-	ServerLogf(time.Now(),
-		"starting on %s with password %q", s.Host, s.Password) // なんで%q?→PWなどの文字列をダブルクォートで囲んで安全に表示するためのフォーマット指定子
-
-	srv := grpc.NewServer()
+	// パスワードはログに残さず、どの方式で認証するかだけを出力する
+	auth := "shared password"
+	if s.Users != nil {
+		auth = "user accounts"
+	}
+	s.Log.Info("starting", "host", s.Host, "auth", auth, "tls", s.Creds != nil)
+
+	// 認証やログなどの共通処理はインターセプタで行い、各ハンドラはビジネスロジックのみを扱う
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryInterceptors()...),
+		grpc.ChainStreamInterceptor(s.streamInterceptors()...),
+		// 猶予期間を過ぎて強制的に止めたときも、ハンドラがブロードキャストチャネルに送信し終えてから閉じる
+		grpc.WaitForHandlers(true),
+	}
+	if s.Creds != nil {
+		opts = append(opts, grpc.Creds(s.Creds))
+	}
+	srv := grpc.NewServer(opts...)
 	chat.RegisterChatServer(srv, s) // サーバにチャットサービスの実装を登録；各種実装はserver構造対に関連付けられている
+	chat.RegisterAdminServer(srv, &admin{s: s})
+	healthpb.RegisterHealthServer(srv, s.Health)
+	// grpcurlなどでサービスの一覧やメッセージの定義を調べられるようにする
+	reflection.Register(srv)
+
+	l := s.Listener
+	if l == nil {
+		var err error
+		if l, err = net.Listen("tcp", s.Host); err != nil {
+			return errors.WithMessage(err, "server unable to bind on provided host")
+		}
+	}
+	if s.MetricsAddr != "" {
+		if err := s.serveMetrics(ctx); err != nil {
+			return err
+		}
+	}
+
+	// 履歴に残っている最後のシーケンス番号から続けて番号を割り当てる
+	if last, err := s.Store.History(roomFilter{}, time.Time{}, 1); err != nil {
+		return errors.WithMessage(err, "unable to load history")
+	} else if len(last) > 0 {
+		s.seq.Store(last[0].Sequence)
+	}
+	if err := s.loadMessages(); err != nil {
+		return errors.WithMessage(err, "unable to load history")
+	}
+	if err := s.loadSessions(); err != nil {
+		return err
+	}
 
-	l, err := net.Listen("tcp", s.Host)
+	// 全てのレプリカから届くイベントを購読する、購読はブロードキャストチャネルを閉じて送信し終えてから解除する
+	subCtx, unsubscribe := context.WithCancel(context.Background())
+	defer unsubscribe()
+	events, err := s.Broker.Subscribe(subCtx)
 	if err != nil {
-		return errors.WithMessage(err, "server unable to bind on provided host")
+		return errors.WithMessage(err, "unable to subscribe to broker")
 	}
+	s.started = time.Now()
+	s.drain(s.draining.Load())
 
-	// サーバからクライアントへのメッセージをブロードキャストをするゴルーチン；サーバー内部で何らかのイベントが発生した際（例えば、新しいメッセージがサーバーに届いた時など）に、その情報をすべての接続中のクライアントに送信、一方向的
-	go s.broadcast(ctx)
+	relayed := make(chan struct{})
+	go func() {
+		s.relay(events)
+		close(relayed)
+	}()
+
+	// サーバからクライアントへのメッセージをブロードキャストをするゴルーチン；サーバー内部で何らかのイベントが発生した際（例えば、新しいメッセージがサーバーに届いた時など）に、その情報をブローカーを通じてすべてのレプリカの接続中のクライアントに送信、一方向的
+	published := make(chan struct{})
+	go func() {
+		s.broadcast()
+		close(published)
+	}()
 
 	// クライアントからの受信処理実際にクライアントからの接続を受け付け、リクエストに応答するための処理を非同期で開始する、クライアントとサーバー間の双方向の通信（リクエストの受信とレスポンスの送信）を管理
 	go func() {
@@ -108,35 +270,66 @@ func (s *server) Run(ctx context.Context) error {
 	// 内部のコンテキストが終了信号を発信するまでクライアントからの処理を担う各種ルーチンの親ルーチンとなるRunメソッドの処理はここで止めておく→これを終了するとリソースの制御などの問題がめんどうくさくなる、ここできちんと止めておいて終わったら各々解放するように実装しておく
 	<-ctx.Done()
 
-	// サーバーのシャットダウンをクライアントに通知するメッセージをブロードキャストチャネルに送信；接続されているクライアントがサーバー停止を知ることができる
-	s.Broadcast <- &chat.StreamResponse{
-		Timestamp: timestamppb.Now(),
-		Event: &chat.StreamResponse_ServerShutdown{
-			ServerShutdown: &chat.StreamResponse_Shutdown{},
-		}}
+	// 新しいログインを止めてクライアントにシャットダウンを通知し、ストリームを送り終えるか猶予期間が過ぎるまで待つ
+	// 実行中のRPCがブロードキャストチャネルに送信し終えてからチャネルを閉じる
+	s.shutdown(srv)
 	close(s.Broadcast)
-	ServerLogf(time.Now(), "shutting down")
-
-	// gRPCサーバを安全にシャットダウン；グレースフル：優美な、らしい意味わからん笑、いやわかるけど
-	srv.GracefulStop()
+	<-published
+	unsubscribe()
+	<-relayed
 	return nil
 }
 
-func (s *server) Login(_ context.Context, req *chat.LoginRequest) (*chat.LoginResponse, error) {
+func (s *server) Login(ctx context.Context, req *chat.LoginRequest) (_ *chat.LoginResponse, err error) {
+	defer func() {
+		if err != nil {
+			s.Prometheus.loginFailed(status.Code(err))
+		}
+	}()
+	// シャットダウンに備えてドレインしている間は新しいログインを受け付けない
+	if s.draining.Load() {
+		return nil, status.Error(codes.Unavailable, "server is draining and not accepting new logins")
+	}
+	// 共通のパスワードの総当たりを防ぐため、同じIPアドレスからの試行の頻度を制限する
+	if err := s.checkLogin(ctx); err != nil {
+		return nil, err
+	}
 	// Golangの仕様ではここのswitch文は実行されるのか
 	switch {
-	// PWの検証
-	case req.Password != s.Password:
-		return nil, status.Error(codes.Unauthenticated, "password is incorrect") // codes.Unauthenticated: 認証エラー
 	// 名前の検証
 	case req.Name == "":
 		return nil, status.Error(codes.InvalidArgument, "name cannot be empty") // codes.InvalidArgument: 引数エラー
+	// 公開鍵は省略できるが、指定するときはX25519の鍵の長さでなければならない
+	case len(req.PublicKey) > 0 && e2e.ValidPublicKey(req.PublicKey) != nil:
+		return nil, status.Errorf(codes.InvalidArgument, "public key must be %d bytes", e2e.KeySize)
+	// アカウントが有効なときはユーザ名とPWの組を検証
+	case s.Users != nil:
+		if err := s.Users.Check(req.Name, req.Password); err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+	// PWの検証
+	case req.Password != s.Password:
+		return nil, status.Error(codes.Unauthenticated, "password is incorrect") // codes.Unauthenticated: 認証エラー
+	}
+	// モデレータに禁止されたユーザはログインできない
+	if until, banned := s.Bans.Banned(req.Name); banned {
+		return nil, status.Error(codes.PermissionDenied, "you are banned"+untilText(timestamp(until)))
+	}
+	// 署名付きのトークンを発行し、セッションIDをサーバの管理下に登録
+	tkn, payload, err := s.Tokens.CreateToken(req.Name, s.TokenTTL)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to issue token")
+	}
+	// クライアント名とそれに対応するセッションIDをサーバ構造体のマップに格納、同じ名前で既にログインしているときは拒否
+	s.evictExpired(req.Name)
+	if !s.claimName(payload.ID, req.Name) {
+		return nil, status.Error(codes.AlreadyExists, "name is already logged in")
 	}
-	// トークンを発行してサーバの管理下に登録
-	tkn := s.genToken()
-	s.setName(tkn, req.Name) // クライアント名とそれに対応するトークンをサーバ構造体のマップに格納
+	s.setPresence(payload.ID, payload.ExpiredAt)
+	s.setPublicKey(payload.ID, req.Name, req.PublicKey)
+	s.joinRoom(payload.ID, defaultRoom)
 
-	ServerLogf(time.Now(), "%s (%s) has ogged in", tkn, req.Name)
+	s.Log.Info("logged in", "token", payload.ID, "name", req.Name)
 
 	// あるクライアントがサーバーにログインすると、その情報がサーバーに接続している全クライアントにリアルタイムで共有されることになります。これは、チャットアプリケーションにおいて、新しいユーザーが参加したことを他の参加者に知らせるための重要な機能の一つ
 	s.Broadcast <- &chat.StreamResponse{
@@ -150,18 +343,23 @@ func (s *server) Login(_ context.Context, req *chat.LoginRequest) (*chat.LoginRe
 
 	// Token: tknは、ログイン成功時にクライアントに返す認証トークンを設定し返す。クライアントは後続のリクエストで自身を認証するために使用する
 	// nilはエラー情報を渡すためのスペース、正常に終了したためnilを返す
-	return &chat.LoginResponse{Token: tkn}, nil
+	return &chat.LoginResponse{Token: tkn, ExpiresAt: timestamppb.New(payload.ExpiredAt)}, nil
 
 }
 
-func (s *server) Logout(_ context.Context, req *chat.LogoutRequest) (*chat.LogoutResponse, error) {
-	name, ok := s.delName(req.Token)
+func (s *server) Logout(ctx context.Context, _ *chat.LogoutRequest) (*chat.LogoutResponse, error) {
+	payload := sessionFrom(ctx)
+	name, ok := s.delName(payload.ID)
 	if !ok {
 		// code.(...):gRPCで定義されているエラーコード
 		return nil, status.Error(codes.NotFound, "token not found")
 	}
+	s.leaveAllRooms(payload.ID)
+	s.delPresence(payload.ID, name)
+	// ログアウト後に同じトークンでセッションが復元されないようにする
+	s.revoke(payload)
 
-	ServerLogf(time.Now(), "%s (%s) has logged out", req.Token, name)
+	s.Log.Info("logged out", "token", payload.ID, "name", name)
 
 	s.Broadcast <- &chat.StreamResponse{
 		Timestamp: timestamppb.Now(),
@@ -176,117 +374,331 @@ func (s *server) Logout(_ context.Context, req *chat.LogoutRequest) (*chat.Logou
 
 // gRPCのストリーミングRPCを実装するメソッド
 func (s *server) Stream(srv chat.Chat_StreamServer) error {
-	// サーバが保持するトークン情報を抽出
-	tkn, ok := s.extractToken(srv.Context())
-
-	if !ok {
-		return status.Error(codes.Unauthenticated, "missing token header") // なぜここはheaderと記述されているか
-	}
-
-	name, ok := s.getName(tkn)
-	if !ok {
-		return status.Error(codes.Unauthenticated, "Invalid token")
+	// 認証インターセプタで検証済みのセッションを取り出し、以降はセッションIDをキーとして扱う
+	payload := sessionFrom(srv.Context())
+	tkn, name := payload.ID, payload.Username
+
+	// 送信ループが終了する(切断される)か、受信でエラーが起きるまでストリームを維持する
+	sendErr := make(chan error, 1)
+	go func() { sendErr <- s.sendBroadcasts(srv, tkn, extractSequence(srv.Context())) }()
+	recvErr := make(chan error, 1)
+	go func() { recvErr <- s.receiveRequests(srv, tkn, name) }()
+
+	select {
+	case err := <-sendErr:
+		return err
+	case err := <-recvErr:
+		if err != nil {
+			return err
+		}
+		// クライアントが送信側だけを閉じた場合は受信を続けられるので、送信ループの終了を待つ
+		return <-sendErr
 	}
+}
 
-	go s.sendBroadcasts(srv, tkn)
-
+// クライアントからのメッセージを受け取り、ブロードキャストするメソッド、クライアントが送信を終えたときはnilを返す
+func (s *server) receiveRequests(srv chat.Chat_StreamServer, tkn, name string) error {
 	for {
 		req, err := srv.Recv() // クライアントからの新しいメッセージを待機し、それを返す関数、クライアントがストリームを閉じるとio.EOFエラーを返す
 		if err == io.EOF {
-			break
+			return nil
 		} else if err != nil {
 			return err
 		}
 
+		s.touch(tkn)
+
+		// 発言を禁止されているユーザのメッセージや入力中の通知は配信しない
+		if until, ok := s.muted(name); ok {
+			if req.GetStartTyping() == nil && req.GetStopTyping() == nil {
+				s.sendError(tkn, "you are muted"+untilText(timestamp(until)))
+			}
+			continue
+		}
+
+		// 1人のクライアントがブロードキャストチャネルを埋めないよう、発言の頻度と大きさ、入力中の通知の頻度を制限する
+		if req.GetStartTyping() != nil || req.GetStopTyping() != nil {
+			if !s.checkTyping(tkn) {
+				s.Log.Debug("rate limited, dropping typing state", "token", tkn, "name", name)
+				continue
+			}
+		} else if limit, warning := s.checkMessage(tkn, req); warning != "" {
+			s.Prometheus.rateLimited(limit)
+			s.Log.Debug("rate limited, dropping request", "token", tkn, "name", name, "limit", limit)
+			s.sendError(tkn, warning)
+			continue
+		}
+
+		// 入力中の通知やメッセージの編集などは発言としては扱わない
+		switch action := req.Action.(type) {
+		case *chat.StreamRequest_StartTyping, *chat.StreamRequest_StopTyping:
+			s.typing(tkn, name, req)
+			continue
+		case *chat.StreamRequest_EditMessage:
+			s.editMessage(tkn, name, action.EditMessage)
+			continue
+		case *chat.StreamRequest_DeleteMessage:
+			s.deleteMessage(tkn, name, action.DeleteMessage)
+			continue
+		case *chat.StreamRequest_React:
+			s.react(tkn, name, action.React)
+			continue
+		}
+
+		// 宛先が指定されている場合はダイレクトメッセージとして処理
+		if req.Recipient != "" {
+			s.directMessage(tkn, name, req)
+			continue
+		}
+
+		room := normalizeRoom(req.Room)
+		// "/kick"などのサーバ側のコマンド
+		if strings.HasPrefix(req.Message, "/") && s.command(tkn, name, room, req.Message) {
+			continue
+		}
+		// 参加していないルームへの発言はブロードキャストせずに破棄
+		if !s.inRoom(room, tkn) {
+			s.Log.Debug("not a member of the room, dropping message", "token", tkn, "name", name, "room", room)
+			continue
+		}
+
 		s.Broadcast <- &chat.StreamResponse{
 			Timestamp: timestamppb.Now(),
 			Event: &chat.StreamResponse_ClientMessage{
 				ClientMessage: &chat.StreamResponse_Message{
 					Name:    name,
 					Message: req.Message,
+					Room:    room,
+					Id:      newMessageID(),
 				},
 			},
 		}
 	}
-
-	<-srv.Context().Done()
-	return srv.Context().Err() // gRPCサーバとの接続が閉じられたときに閉じられたチャネルを
 }
 
-func (s *server) sendBroadcasts(srv chat.Chat_StreamServer, tkn string) {
+// クライアントのストリームに溜まったメッセージを送信し続けるメソッド、接続が終了したときや切断したときにその理由を返す
+func (s *server) sendBroadcasts(srv chat.Chat_StreamServer, tkn string, resumeFrom uint64) error {
 	// トークンを使用してストリームを生成し、同様に閉じる
-	stream := s.openStream(tkn)
-	defer s.closeStream(tkn)
+	stream, history := s.openStream(tkn, resumeFrom)
+	defer s.closeStream(tkn, stream)
+
+	// ストリームの登録が済んだことをクライアントに知らせる
+	if err := srv.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	// 接続前の直近のメッセージを先に再送して、途中から参加したクライアントにも文脈がわかるようにする
+	for _, res := range history {
+		if err := srv.Send(res); err != nil {
+			s.Log.Debug("failed to replay history", "token", tkn, "err", err)
+			return err
+		}
+	}
 
 	for {
 		select {
 		case <-srv.Context().Done():
-			return
-		case res := <-stream:
-			if s, ok := status.FromError(srv.Send(res)); ok {
-				switch s.Code() {
-				case codes.OK:
-					// noop
-				case codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
-					DebugLogf("clinet (%s) terminated connection", tkn)
-					return
-				default:
-					ClientLogf(time.Now(), "failed to send to client (%s): %v", tkn, s.Err())
-					return
-				}
+			return srv.Context().Err() // gRPCサーバとの接続が閉じられたときに閉じられたチャネルを
+		case <-stream.closing:
+			return s.flush(srv, tkn, stream)
+		case <-stream.kicked:
+			s.Log.Warn("client is too slow, disconnecting", "token", tkn)
+			return status.Error(codes.ResourceExhausted, "client is too slow to receive messages")
+		case <-stream.ready:
+			// 1件ずつ取り出し、残っていればstream.readyに再び通知が入る
+			e, ok := stream.pop()
+			if !ok {
+				continue
 			}
+			if err := s.sendQueued(srv, tkn, e); err != nil {
+				return err
+			}
+			// シャットダウンやキックを知らせたらストリームを終了する
+			if e.res.GetServerShutdown() != nil || e.res.GetClientKicked() != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// 1件のイベントをクライアントに送信するメソッド
+func (s *server) send(srv chat.Chat_StreamServer, tkn string, res *chat.StreamResponse) error {
+	err := srv.Send(res)
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.OK:
+			// noop
+		case codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
+			s.Log.Debug("client terminated connection", "token", tkn)
+		default:
+			s.Log.Error("failed to send to client", "token", tkn, "err", st.Err())
 		}
 	}
+	return err
 }
 
-func (s *server) broadcast(_ context.Context) {
+// ブロードキャストチャネルに送られたイベントをブローカーへ送信するメソッド、シャットダウンの通知はこのレプリカのクライアントにだけ配信する
+func (s *server) broadcast() {
 	for res := range s.Broadcast {
-		s.streamsMtx.RLock()
-		for _, stream := range s.ClientStreams {
-			select {
-			case stream <- res:
-				// noop
-			default:
-				ServerLogf2(time.Now(), "client stream is full, dropping message")
+		if res.GetServerShutdown() != nil {
+			s.fanOut(res)
+			continue
+		}
+
+		// Runのコンテキストが終了した後のログアウトなども送信できるよう、イベントごとに期限を設ける
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := s.publish(ctx, res)
+		cancel()
+		if err != nil {
+			s.droppedTotal.Add(1)
+			s.Prometheus.dropped.WithLabelValues(dropPublishFailed).Inc()
+			s.Log.Error("failed to publish message", "err", err)
+			continue
+		}
+		s.Prometheus.broadcast.Inc()
+	}
+}
+
+// 履歴に残すイベントにシーケンス番号を割り当ててブローカーへ送信するメソッド
+func (s *server) publish(ctx context.Context, res *chat.StreamResponse) error {
+	if recordable(res) {
+		seq, err := s.Broker.NextSequence(ctx, s.seq.Load())
+		if err != nil {
+			return err
+		}
+		res.Sequence = seq
+	}
+	return s.Broker.Publish(ctx, res)
+}
+
+// ブローカーから届いたイベントをこのレプリカのクライアントへ配信するメソッド、購読が終了するまで続ける
+func (s *server) relay(events <-chan *chat.StreamResponse) {
+	for res := range events {
+		s.fanOut(res)
+	}
+}
+
+// イベントを履歴に保存し、受け取るべき全てのクライアントのストリームに入れるメソッド
+func (s *server) fanOut(res *chat.StreamResponse) {
+	blocked := s.enqueue(res)
+
+	// 一杯のストリームの空きを待つ間も他のストリームの登録や解放を止めないよう、ロックを解放してから並行して待つ
+	var wg sync.WaitGroup
+	for tkn, stream := range blocked {
+		wg.Add(1)
+		go func(tkn string, stream *clientStream) {
+			defer wg.Done()
+			s.waitDeliver(tkn, stream, res)
+		}(tkn, stream)
+	}
+	wg.Wait()
+
+	// キックされたことを知らせてからセッションを終了させる
+	if evt := res.GetClientKicked(); evt != nil {
+		s.endSessions(evt.Name)
+	}
+}
+
+// streamsMtxの読み取りロックを取得してイベントを保存し、各ストリームに入れるメソッド、空きを待つ必要があるストリームを返す
+func (s *server) enqueue(res *chat.StreamResponse) (blocked map[string]*clientStream) {
+	s.streamsMtx.RLock()
+	defer s.streamsMtx.RUnlock()
+
+	// ストリームの登録と履歴の保存が前後しないように、ロックを取得してから保存する
+	if recordable(res) {
+		s.observeSequence(res.Sequence)
+		if err := s.Store.Append(res); err != nil {
+			s.Log.Error("failed to record message", "err", err)
+		}
+		s.trackMessage(res)
+		s.Index.update(res)
+	}
+	s.countMessage(res)
+	if mod := res.GetModerationAction(); mod != nil {
+		s.applyModeration(mod)
+	}
+	for tkn, stream := range s.ClientStreams {
+		// ルーム宛てのメッセージはそのルームの参加者にのみ配信
+		if !s.shouldReceive(tkn, res) {
+			continue
+		}
+		if !s.deliver(tkn, stream, res) {
+			if blocked == nil {
+				blocked = make(map[string]*clientStream)
 			}
+			blocked[tkn] = stream
 		}
-		s.streamsMtx.RUnlock()
 	}
+	return blocked
 }
 
-func (s *server) openStream(tkn string) (stream chan *chat.StreamResponse) {
-	stream = make(chan *chat.StreamResponse, 100)
+// 配信したシーケンス番号がこれまでの最大値より大きければ更新するメソッド
+func (s *server) observeSequence(seq uint64) {
+	for {
+		cur := s.seq.Load()
+		if seq <= cur || s.seq.CompareAndSwap(cur, seq) {
+			return
+		}
+	}
+}
+
+// ストリームを生成するメソッド、登録時点までにブロードキャストされた再送用の履歴も合わせて返す
+// resumeFromが0より大きいときは、再接続したクライアントが受け取っていないそれ以降のイベントを返す
+func (s *server) openStream(tkn string, resumeFrom uint64) (stream *clientStream, history []*chat.StreamResponse) {
+	stream = newClientStream()
 
+	// 登録した時点までに配信されたイベントは全て保存済みで、それより後のイベントはストリームに入る
+	// 同じトークンで既に開いているストリームは、溜まっているメッセージを送った後に終了させる
 	s.streamsMtx.Lock()
+	if old, ok := s.ClientStreams[tkn]; ok {
+		name, _ := s.getName(tkn)
+		old.close(kicked(name, "", "the session was opened on another connection"))
+	}
 	s.ClientStreams[tkn] = stream
+	upTo := s.seq.Load()
 	s.streamsMtx.Unlock()
 
-	DebugLogf("opened stream for client %s", tkn)
+	// 履歴はファイルから読むことがあるので、配信を止めないようロックを解放してから読み込む
+	var (
+		events []*chat.StreamResponse
+		err    error
+	)
+	switch {
+	// サーバが再起動して番号が巻き戻っているときは通常の再送にする
+	case resumeFrom > 0 && resumeFrom <= upTo:
+		events, err = s.Store.After(resumeFrom, maxHistoryLimit)
+	case s.Replay > 0:
+		events, err = s.Store.History(roomFilter{room: defaultRoom}, time.Time{}, s.Replay)
+	}
+	if err != nil {
+		s.Log.Error("failed to load history", "token", tkn, "err", err)
+	}
+	// 登録の後に保存されたイベントはストリームで届くので、重複しないよう除く
+	for _, res := range events {
+		if res.Sequence <= upTo && s.shouldReceive(tkn, res) {
+			history = append(history, res)
+		}
+	}
+
+	s.Log.Debug("opened stream", "token", tkn)
 
 	return
 }
 
-// ストリームを解放するメソッド
-func (s *server) closeStream(tkn string) {
+// ストリームを解放するメソッド、同じトークンで新しいストリームが開かれているときはその登録を残す
+func (s *server) closeStream(tkn string, stream *clientStream) {
 	s.streamsMtx.Lock()
 
-	if stream, ok := s.ClientStreams[tkn]; ok {
+	if s.ClientStreams[tkn] == stream {
 		delete(s.ClientStreams, tkn) // サーバメンバのストリームリストから登録の解除
-		close(stream)                // ストリームの解放
 	}
+	close(stream.done) // ストリームの解放、空きを待っている配信も諦める
 
-	DebugLogf("closed stream for client %s", tkn)
+	s.Log.Debug("closed stream", "token", tkn)
 
 	s.streamsMtx.Unlock()
 }
 
-// トークンを生成するメソッド
-func (s *server) genToken() string {
-	tkn := make([]byte, 4) // 4byteってセキュリティ的に大丈夫だっけ。簡易的なサンプルだからここら辺はいいのかもだけど
-	rand.Read(tkn)
-	return fmt.Sprintf("%x", tkn)
-}
-
 // てかまずそもそもメソッドを実行するときにMtxのLock,RLock使ってメンバへのアクセスを他のゴルーチンからロックする必要があるのか(確認)：Lock() や RLock() を使用する理由は、複数のゴルーチンが同時に s.ClientNames にアクセスする可能性があるためで、競合状態やデータの破損を防ぐことができる。
 //　Lock(): 書き込みアクセス、メンバへのRW両方のアクセスを拒否、つまり占有ロック、排他的なロックをする
 // RLock():読み取りアクセスをするときに使用。他のゴルーチンは書き込みを行えなくなるが、読み取りは複数のゴルーチンで同時に行うことができる。つまり、共有ロックをする
@@ -299,11 +711,18 @@ func (s *server) getName(tkn string) (name string, ok bool) {
 	return
 }
 
-// 名前を設定するメソッド
-func (s *server) setName(tkn string, name string) {
+// 同じ名前のセッションが無いときに限り名前を設定するメソッド、確認と設定を一度のロックで行う
+func (s *server) claimName(tkn string, name string) bool {
 	s.namesMtx.Lock()
+	defer s.namesMtx.Unlock()
+
+	for _, n := range s.ClientNames {
+		if n == name {
+			return false
+		}
+	}
 	s.ClientNames[tkn] = name
-	s.namesMtx.Unlock()
+	return true
 }
 
 // 名前の削除
@@ -318,16 +737,3 @@ func (s *server) delName(tkn string) (name string, ok bool) {
 
 	return
 }
-
-func (s *server) extractToken(ctx context.Context) (tkn string, ok bool) {
-	// コンテキストからメタデータを取得するメソッド、gRPCのrクエストにはメタデータが含まれており、mdに取得したメタデータを格納
-	// metadata:gRPCのメタデータを操作するためのパッケージ
-	md, ok := metadata.FromIncomingContext(ctx)
-	// トークンが見つからなかったときの処理
-	if !ok || len(md[tokenHeader]) == 0 {
-		return "", false
-	}
-	// md[tokenHeader] は、トークンヘッダーに関連付けられたすべての値のスライス、通常、HTTPヘッダーには1つの値しか含まれないため、最初の値 [0] を取得
-	return md[tokenHeader][0], true
-
-}
@@ -0,0 +1,258 @@
+package session
+
+import (
+	"fmt"
+	"log/slog"
+	mrand "math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// サーバとのセッションの管理用パッケージ
+// ログインしてトークンを受け取り、期限が切れる前に更新し、ストリームが切れたときは再接続し、終了するときにログアウトする
+// 端末のクライアントとボットが同じ手順で接続するよう、両方から使う
+
+// クライアントとサーバの間でgRPCのメタデータとしてやり取りするヘッダ
+const (
+	// セッショントークンを送るヘッダ
+	TokenHeader = "x-chat-token"
+	// 再接続したクライアントが最後に受け取ったシーケンス番号を送るヘッダ
+	ResumeHeader = "x-chat-last-seq"
+	// HTTPブリッジが中継したリクエストの送信元のIPアドレスを送るヘッダ、信頼するブリッジから届いたときだけ使う
+	ClientIPHeader = "x-chat-client-ip"
+)
+
+const (
+	// 再接続を諦めるまでの連続した失敗回数のデフォルト値
+	DefaultMaxReconnects = 10
+	// 再接続の待ち時間の初期値と上限、失敗するたびに倍にしてジッターを加える
+	reconnectBaseDelay = 250 * time.Millisecond
+	reconnectMaxDelay  = 30 * time.Second
+	// ストリーム以外の1回のRPCを諦めるまでの時間
+	rpcTimeout = time.Second
+)
+
+// ErrEnded is returned by a StreamFunc when the server ended the session, Run then stops without reconnecting or logging out
+var ErrEnded = errors.New("the session was ended by the server")
+
+// StreamFunc opens a stream with ctx, which carries the token and the resume position, and handles it until it closes
+// 1件でもイベントを受け取れたかどうかを返す、受け取れたときは再接続の失敗回数を数え直す
+type StreamFunc func(ctx context.Context) (established bool, err error)
+
+// Session logs in to the server and keeps a stream open until its context ends
+type Session struct {
+	Client         chat.ChatClient
+	Name, Password string
+	// ログイン時にサーバへ公開するエンドツーエンド暗号化の公開鍵、nilのときは公開しない
+	PublicKey []byte
+	// ストリームが切断されたときに再接続を試みる連続した回数の上限、0のときは再接続しない
+	MaxReconnects int
+	// 診断用のログの出力先
+	Log *slog.Logger
+	// 接続の状況を利用者に知らせる関数、nilのときはLogに出力する
+	Notify func(format string, args ...any)
+
+	token  string
+	expiry time.Time
+	// 最後に受け取ったイベントのシーケンス番号、再接続時にサーバへ送り取りこぼしを再送してもらう
+	lastSeq uint64
+	// トークンの更新とストリームやコマンドからの参照が同時に行われることを防ぐ
+	mtx sync.RWMutex
+}
+
+// セッションを生成する関数
+func New(name, password string) *Session {
+	return &Session{
+		Name:          name,
+		Password:      password,
+		MaxReconnects: DefaultMaxReconnects,
+		Log:           slog.Default(),
+	}
+}
+
+// クライアントが送るメタデータを付与したコンテキストを返す関数、lastSeqが0のときは再開位置を送らない
+func OutgoingContext(ctx context.Context, tkn string, lastSeq uint64) context.Context {
+	md := metadata.Pairs(TokenHeader, tkn)
+	if lastSeq > 0 {
+		md.Set(ResumeHeader, strconv.FormatUint(lastSeq, 10))
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// Run はログインしてstreamを呼び、ctxが終了するまでストリームを開き直し続ける
+// ストリームが切れたときは待ち時間を伸ばしながら再接続し、トークンが使えなくなっていればログインし直す、終了するときにログアウトする
+func (s *Session) Run(ctx context.Context, stream StreamFunc) error {
+	if err := s.Login(ctx); err != nil {
+		return errors.WithMessage(err, "failed to login")
+	}
+	s.notify("logged in successfully")
+
+	// 有効期限が切れる前にトークンを更新し続ける
+	refreshCtx, stopRefresh := context.WithCancel(ctx)
+	defer stopRefresh()
+	go s.refresh(refreshCtx)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var established bool
+		established, err = stream(OutgoingContext(ctx, s.Token(), s.LastSeq()))
+		if errors.Is(err, ErrEnded) {
+			return nil
+		}
+		// 呼び出し側が止めたときはエラーにしない
+		if ctx.Err() != nil {
+			err = nil
+			break
+		}
+		if established {
+			attempt = 0
+		}
+		if attempt >= s.MaxReconnects {
+			break
+		}
+
+		delay := backoff(attempt)
+		s.notify("disconnected from stream (%v), reconnecting in %s", err, delay.Round(time.Millisecond))
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			err = nil
+			break
+		}
+
+		// トークンが期限切れなどで使えなくなっているときだけログインし直す
+		if status.Code(err) == codes.Unauthenticated {
+			if err := s.Login(ctx); err != nil {
+				s.notify("failed to login again: %v", err)
+				continue
+			}
+			s.notify("logged in again")
+		}
+	}
+
+	s.notify("logging out")
+	if err := s.logout(); err != nil {
+		s.notify("failed to logout: %v", err)
+	}
+	return errors.WithMessage(err, "stream error")
+}
+
+// 試行回数に応じた再接続までの待ち時間を返す関数、待ち時間の上限の範囲でランダムにばらつかせて、多数のクライアントが同時に再接続しないようにする
+func backoff(attempt int) time.Duration {
+	max := reconnectBaseDelay << uint(attempt)
+	if max <= 0 || max > reconnectMaxDelay {
+		max = reconnectMaxDelay
+	}
+	return reconnectBaseDelay/2 + time.Duration(mrand.Int63n(int64(max)))
+}
+
+// Login はNameとPasswordでログインしてトークンを受け取る
+func (s *Session) Login(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	res, err := s.Client.Login(ctx, &chat.LoginRequest{
+		Name:      s.Name,
+		Password:  s.Password,
+		PublicKey: s.PublicKey,
+	})
+	if err != nil {
+		return err
+	}
+	s.setToken(res.Token, res.ExpiresAt.AsTime())
+	return nil
+}
+
+// トークンの有効期間の8割が過ぎたところでRefreshを呼び、新しいトークンに交換し続けるメソッド
+func (s *Session) refresh(ctx context.Context) {
+	for {
+		s.mtx.RLock()
+		wait := time.Until(s.expiry) * 4 / 5
+		s.mtx.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		rctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+		res, err := s.Client.Refresh(rctx, &chat.RefreshRequest{Token: s.Token()})
+		cancel()
+		if err != nil {
+			// 接続が切れている間などは少し待ってからやり直す、期限が切れた場合は再接続時のログインで新しいトークンになる
+			s.notify("failed to refresh token: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reconnectMaxDelay):
+			}
+			continue
+		}
+		s.setToken(res.Token, res.ExpiresAt.AsTime())
+		s.Log.Debug("token refreshed", "expires_at", res.ExpiresAt.AsTime())
+	}
+}
+
+// ログアウトするメソッド、Runのctxが終了していても送れるよう別のコンテキストを使う
+func (s *Session) logout() error {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+
+	_, err := s.Client.Logout(ctx, &chat.LogoutRequest{Token: s.Token()})
+	if status.Code(err) == codes.Unavailable {
+		s.Log.Debug("unable to logout (connection already closed)")
+		return nil
+	}
+	return err
+}
+
+// Token は現在のトークンを返す
+func (s *Session) Token() string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.token
+}
+
+// トークンとその有効期限を更新するメソッド
+func (s *Session) setToken(tkn string, expiry time.Time) {
+	s.mtx.Lock()
+	s.token, s.expiry = tkn, expiry
+	s.mtx.Unlock()
+}
+
+// Received は受け取ったイベントのシーケンス番号を覚え、再接続したときに続きから受け取れるようにする
+func (s *Session) Received(seq uint64) {
+	s.mtx.Lock()
+	if seq > s.lastSeq {
+		s.lastSeq = seq
+	}
+	s.mtx.Unlock()
+}
+
+// LastSeq は最後に受け取ったイベントのシーケンス番号を返す
+func (s *Session) LastSeq() uint64 {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.lastSeq
+}
+
+// 接続の状況を利用者に知らせるメソッド
+func (s *Session) notify(format string, args ...any) {
+	if s.Notify != nil {
+		s.Notify(format, args...)
+		return
+	}
+	s.Log.Info(fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,28 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBackoff(t *testing.T) {
+	// 待ち時間は試行回数とともに伸び、上限を超えない
+	for attempt := 0; attempt < 64; attempt++ {
+		d := backoff(attempt)
+		require.GreaterOrEqual(t, d, reconnectBaseDelay/2)
+		require.Less(t, d, reconnectBaseDelay/2+reconnectMaxDelay)
+	}
+	require.Less(t, backoff(0), reconnectBaseDelay/2+reconnectBaseDelay)
+}
+
+func TestOutgoingContext(t *testing.T) {
+	md, _ := metadata.FromOutgoingContext(OutgoingContext(context.Background(), "tkn", 0))
+	require.Equal(t, []string{"tkn"}, md.Get(TokenHeader))
+	require.Empty(t, md.Get(ResumeHeader))
+
+	md, _ = metadata.FromOutgoingContext(OutgoingContext(context.Background(), "tkn", 42))
+	require.Equal(t, []string{"42"}, md.Get(ResumeHeader))
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// シャットダウンの猶予期間のデフォルト値、過ぎても閉じていないストリームは強制的に閉じる
+const defaultShutdownGrace = 10 * time.Second
+
+// シャットダウン時に保存するセッションの状態、再起動後に同じトークンで再接続したセッションのルームなどを復元する
+type sessionSnapshot struct {
+	SavedAt  time.Time       `json:"saved_at"`
+	Sessions []*savedSession `json:"sessions"`
+	// ログアウトしたセッションIDとそのトークンの有効期限、再起動後も同じトークンでの再接続を拒否する
+	Revoked map[string]time.Time `json:"revoked"`
+}
+
+// 保存した1つのセッション
+type savedSession struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Rooms     []string  `json:"rooms"`
+	LoginTime time.Time `json:"login_time"`
+	Expiry    time.Time `json:"expiry"`
+	PublicKey []byte    `json:"public_key,omitempty"`
+}
+
+// 新しいログインを止め、クライアントのストリームに溜まったメッセージを送り終えてからサーバを停止するメソッド
+// 猶予期間を過ぎても閉じていないストリームは強制的に閉じ、最後にセッションの状態を保存する
+func (s *server) shutdown(srv *grpc.Server) {
+	deadline := time.Now().Add(s.ShutdownGrace)
+	s.drain(true)
+	s.Log.Info("shutting down", "grace", s.ShutdownGrace)
+
+	// 各ストリームは溜まっているメッセージの後にこの通知を送って終了する
+	s.Broadcast <- &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Event: &chat.StreamResponse_ServerShutdown{
+			ServerShutdown: &chat.StreamResponse_Shutdown{
+				GraceSeconds: int32(s.ShutdownGrace.Round(time.Second) / time.Second),
+				Deadline:     timestamppb.New(deadline),
+			},
+		}}
+	s.Health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	t := time.NewTimer(time.Until(deadline))
+	defer t.Stop()
+	select {
+	case <-stopped:
+	case <-t.C:
+		s.streamsMtx.RLock()
+		n := len(s.ClientStreams)
+		s.streamsMtx.RUnlock()
+		s.Log.Warn("grace period expired, closing remaining streams", "streams", n)
+		srv.Stop()
+		<-stopped
+	}
+
+	if err := s.saveSessions(); err != nil {
+		s.Log.Error("failed to save sessions", "err", err)
+	}
+}
+
+// シャットダウンのときに溜まっているメッセージを全て送り、最後にシャットダウンの通知を送るメソッド
+func (s *server) flush(srv chat.Chat_StreamServer, tkn string, stream *clientStream) error {
+	for {
+		e, ok := stream.pop()
+		if !ok {
+			break
+		}
+		if err := s.sendQueued(srv, tkn, e); err != nil {
+			return err
+		}
+	}
+	// 最後に送ったイベントより後に破棄したメッセージがあれば、その件数を知らせてから終了する
+	if n := stream.takeDropped(); n > 0 {
+		if err := s.send(srv, tkn, messagesDropped(n)); err != nil {
+			return err
+		}
+	}
+	return s.send(srv, tkn, stream.farewell)
+}
+
+// ログイン中のセッションと失効したセッションをSessionsFileに保存するメソッド、SessionsFileが空のときは何もしない
+func (s *server) saveSessions() error {
+	if s.SessionsFile == "" {
+		return nil
+	}
+
+	snap := &sessionSnapshot{SavedAt: time.Now(), Revoked: make(map[string]time.Time)}
+
+	s.namesMtx.RLock()
+	for tkn, name := range s.ClientNames {
+		snap.Sessions = append(snap.Sessions, &savedSession{ID: tkn, Name: name})
+	}
+	s.namesMtx.RUnlock()
+	for _, sess := range snap.Sessions {
+		p := s.getPresence(sess.ID)
+		sess.LoginTime, sess.Expiry, sess.PublicKey = p.LoginTime, p.Expiry, p.PublicKey
+	}
+	sort.Slice(snap.Sessions, func(i, j int) bool { return snap.Sessions[i].ID < snap.Sessions[j].ID })
+
+	s.roomsMtx.RLock()
+	for _, sess := range snap.Sessions {
+		for room, members := range s.Rooms {
+			if _, ok := members[sess.ID]; ok {
+				sess.Rooms = append(sess.Rooms, room)
+			}
+		}
+		sort.Strings(sess.Rooms)
+	}
+	s.roomsMtx.RUnlock()
+
+	s.revokedMtx.Lock()
+	for id, exp := range s.Revoked {
+		snap.Revoked[id] = exp
+	}
+	s.revokedMtx.Unlock()
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "unable to encode session file")
+	}
+	if err := writeFileAtomic(s.SessionsFile, data); err != nil {
+		return errors.WithMessage(err, "unable to write session file")
+	}
+	s.Log.Info("saved sessions", "path", s.SessionsFile, "sessions", len(snap.Sessions), "revoked", len(snap.Revoked))
+	return nil
+}
+
+// SessionsFileから前回のシャットダウン時のセッションを読み込むメソッド、有効期限を過ぎたものは読み飛ばす
+// ログイン中だったセッションは名前を確保せず、同じトークンで再接続したときにresumeSessionで復元する
+func (s *server) loadSessions() error {
+	if s.SessionsFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.SessionsFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.WithMessage(err, "unable to read session file")
+	}
+	snap := new(sessionSnapshot)
+	if err := json.Unmarshal(data, snap); err != nil {
+		return errors.WithMessage(err, "unable to parse session file")
+	}
+
+	now := time.Now()
+	s.revokedMtx.Lock()
+	for id, exp := range snap.Revoked {
+		if now.Before(exp) {
+			s.Revoked[id] = exp
+		}
+	}
+	s.revokedMtx.Unlock()
+
+	s.resumeMtx.Lock()
+	for _, sess := range snap.Sessions {
+		if now.Before(sess.Expiry) {
+			s.resumable[sess.ID] = sess
+		}
+	}
+	n := len(s.resumable)
+	s.resumeMtx.Unlock()
+
+	s.Log.Info("loaded sessions", "path", s.SessionsFile, "sessions", n)
+	return nil
+}
+
+// 前回のシャットダウン時に保存したセッションのルームとログイン時刻、公開鍵を復元するメソッド
+func (s *server) resumeSession(tkn string) {
+	s.resumeMtx.Lock()
+	sess, ok := s.resumable[tkn]
+	delete(s.resumable, tkn)
+	s.resumeMtx.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, room := range sess.Rooms {
+		s.joinRoom(tkn, room)
+	}
+	s.presenceMtx.Lock()
+	if p, ok := s.Presence[tkn]; ok {
+		p.LoginTime = sess.LoginTime
+	}
+	s.presenceMtx.Unlock()
+	s.setPublicKey(tkn, sess.Name, sess.PublicKey)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+	"grpc-chat/token"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// 送信したイベントを記録するストリーム
+type recordingStream struct {
+	chat.Chat_StreamServer
+	sent []*chat.StreamResponse
+}
+
+func (r *recordingStream) Send(res *chat.StreamResponse) error {
+	r.sent = append(r.sent, res)
+	return nil
+}
+
+func TestShutdownFlushesFullStream(t *testing.T) {
+	s := Server("bufnet", testPassword)
+	cs := newClientStream()
+	for i := 0; i < streamBufferSize; i++ {
+		s.deliver("tkn", cs, &chat.StreamResponse{Event: &chat.StreamResponse_ClientMessage{ClientMessage: &chat.StreamResponse_Message{Name: "alice"}}})
+	}
+
+	// ストリームが一杯でもシャットダウンの通知は破棄しない
+	s.deliver("tkn", cs, &chat.StreamResponse{Event: &chat.StreamResponse_ServerShutdown{ServerShutdown: new(chat.StreamResponse_Shutdown)}})
+	select {
+	case <-cs.closing:
+	default:
+		t.Fatal("stream was not asked to close")
+	}
+
+	srv := new(recordingStream)
+	require.NoError(t, s.flush(srv, "tkn", cs))
+	require.Len(t, srv.sent, streamBufferSize+1)
+	require.True(t, isShutdown(srv.sent[streamBufferSize]))
+}
+
+func TestShutdownGracePeriod(t *testing.T) {
+	ts := startServer(t, func(s *server) { s.ShutdownGrace = 200 * time.Millisecond })
+	alice := ts.startClient(t, "alice")
+
+	// 監視を続けるストリームはGracefulStopだけでは終わらない
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch, err := healthpb.NewHealthClient(ts.conn(t)).Watch(ctx, new(healthpb.HealthCheckRequest))
+	require.NoError(t, err)
+	_, err = watch.Recv()
+	require.NoError(t, err)
+
+	start := time.Now()
+	ts.stop(t)
+	require.Less(t, time.Since(start), testTimeout/2)
+
+	res := alice.expect(t, "the shutdown", isShutdown)
+	require.Equal(t, int32(0), res.GetServerShutdown().GraceSeconds)
+	require.WithinDuration(t, start.Add(200*time.Millisecond), res.GetServerShutdown().Deadline.AsTime(), time.Second)
+	alice.cancel()
+	require.NoError(t, alice.wait(t))
+}
+
+func TestSessionSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	tokens, err := token.NewHMACMaker(strings.Repeat("k", 32))
+	require.NoError(t, err)
+	opts := func(s *server) {
+		s.Tokens = tokens
+		s.SessionsFile = path
+	}
+
+	ts := startServer(t, opts)
+	alice := ts.startClient(t, "alice")
+	bob := ts.startClient(t, "bob")
+	alice.say(t, "/join dev")
+	require.Eventually(t, func() bool { return roomMembers(ts.server, "dev") == "alice" }, testTimeout, 10*time.Millisecond)
+
+	// ログアウトしたセッションは再起動後も再開できない
+	bob.cancel()
+	require.NoError(t, bob.wait(t))
+	ts.stop(t)
+	require.FileExists(t, path)
+
+	ts = startServer(t, opts)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	c := chat.NewChatClient(ts.conn(t))
+
+	_, err = c.ListRooms(outgoingContext(ctx, bob.token(), 0), new(chat.ListRoomsRequest))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 同じトークンで再接続したセッションは参加していたルームに戻る
+	res, err := c.ListRooms(outgoingContext(ctx, alice.token(), 0), new(chat.ListRoomsRequest))
+	require.NoError(t, err)
+	var rooms []string
+	for _, r := range res.Rooms {
+		if strings.Join(r.Members, ",") == "alice" {
+			rooms = append(rooms, r.Name)
+		}
+	}
+	require.Equal(t, []string{"dev", defaultRoom}, rooms)
+}
+
+func TestSessionResumeAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	tokens, err := token.NewHMACMaker(strings.Repeat("k", 32))
+	require.NoError(t, err)
+	opts := func(s *server) {
+		s.Tokens = tokens
+		s.SessionsFile = path
+	}
+
+	// 再起動した後のサーバにも同じクライアントが接続し直せるよう、接続先を切り替えられるようにする
+	var current atomic.Pointer[testServer]
+	current.Store(startServer(t, opts))
+	alice := runClient(t, "alice", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return current.Load().lis.DialContext(ctx)
+	}))
+	require.Eventually(t, func() bool { return current.Load().isOnline("alice") }, testTimeout, 10*time.Millisecond)
+	alice.say(t, "/join dev")
+	require.Eventually(t, func() bool { return roomMembers(current.Load().server, "dev") == "alice" }, testTimeout, 10*time.Millisecond)
+	tkn := alice.token()
+
+	current.Load().stop(t)
+	alice.expect(t, "the shutdown", isShutdown)
+
+	// ログインし直さずに保存されたセッションを再開し、参加していたルームに戻る
+	ts := startServer(t, opts)
+	current.Store(ts)
+	require.Eventually(t, func() bool { return ts.isOnline("alice") }, testTimeout, 10*time.Millisecond,
+		"alice did not resume the session")
+	require.Equal(t, tkn, alice.token())
+	require.Equal(t, "alice", roomMembers(ts.server, "dev"))
+
+	bob := ts.startClient(t, "bob")
+	bob.say(t, "welcome back")
+	alice.expect(t, "bob's message", messageFrom("bob", "welcome back"))
+}
+
+// ルームの参加者の名前をカンマ区切りで返す関数
+func roomMembers(s *server, room string) string {
+	res, _ := s.ListRooms(context.Background(), new(chat.ListRoomsRequest))
+	for _, r := range res.Rooms {
+		if r.Name == room {
+			return strings.Join(r.Members, ",")
+		}
+	}
+	return ""
+}
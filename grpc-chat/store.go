@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// メモリ上に保持する履歴の件数のデフォルト値
+	defaultHistorySize = 1000
+	// Stream接続時に再送する履歴の件数のデフォルト値
+	defaultReplaySize = 20
+	// History RPCで一度に返せる最大件数
+	maxHistoryLimit = 1000
+	// 履歴のファイルをローテーションする大きさのデフォルト値
+	defaultHistoryMaxBytes = 64 << 20
+)
+
+// ブロードキャストされたメッセージを保存し、過去のメッセージを取り出すためのインターフェース
+type MessageStore interface {
+	// Append はイベントを履歴の末尾に追加する
+	Append(res *chat.StreamResponse) error
+	// History はsinceより後のイベントのうち、roomsに該当する新しいものから最大limit件を古い順に返す、limitが0以下のときは全件
+	History(rooms roomFilter, since time.Time, limit int) ([]*chat.StreamResponse, error)
+	// After はシーケンス番号がseqより大きいイベントを古いものから最大limit件返す、limitが0以下のときは全件
+	After(seq uint64, limit int) ([]*chat.StreamResponse, error)
+	// Close は保存先のリソースを解放する
+	Close() error
+}
+
+// 履歴として保存するイベントかどうかを判定する関数、ダイレクトメッセージやエラーなどの個人宛てのイベントや、入力中の通知は保存しない
+func recordable(res *chat.StreamResponse) bool {
+	switch res.Event.(type) {
+	case *chat.StreamResponse_DirectMessage, *chat.StreamResponse_ServerError,
+		*chat.StreamResponse_Typing, *chat.StreamResponse_StoppedTyping, *chat.StreamResponse_ClientKicked:
+		return false
+	}
+	return true
+}
+
+// 履歴の検索条件に使うイベントの属性、ファイルに書いたイベントはこれだけをメモリ上に持つ
+type historyKey struct {
+	seq    uint64
+	time   time.Time
+	room   string
+	inRoom bool
+}
+
+func keyOf(res *chat.StreamResponse) historyKey {
+	room, ok := eventRoom(res)
+	return historyKey{seq: res.Sequence, time: res.Timestamp.AsTime(), room: room, inRoom: ok}
+}
+
+// 履歴を取り出すルームの条件、ゼロ値は全てのイベントに一致する
+type roomFilter struct {
+	// 空でないときはこのルームのイベントだけ
+	room string
+	// nilでないときは、ルーム宛てでないイベントと、ここに含まれるルームのイベントだけ
+	joined map[string]struct{}
+}
+
+// イベントが履歴の検索条件に一致するかを判定するメソッド
+func (k historyKey) matches(rooms roomFilter, since time.Time) bool {
+	if !since.IsZero() && !k.time.After(since) {
+		return false
+	}
+	if rooms.room != "" {
+		return k.inRoom && k.room == rooms.room
+	}
+	if rooms.joined != nil && k.inRoom {
+		_, ok := rooms.joined[k.room]
+		return ok
+	}
+	return true
+}
+
+// イベントが履歴の検索条件に一致するかを判定する関数
+func matchHistory(res *chat.StreamResponse, rooms roomFilter, since time.Time) bool {
+	return keyOf(res).matches(rooms, since)
+}
+
+// ルームでの発言とその編集・削除・リアクション、ファイルの添付、ルーム宛てのお知らせのイベントについて、そのルームを返す関数
+func eventRoom(res *chat.StreamResponse) (string, bool) {
+	switch evt := res.Event.(type) {
+	case *chat.StreamResponse_ClientMessage:
+		return evt.ClientMessage.Room, true
+	case *chat.StreamResponse_MessageEdited:
+		return evt.MessageEdited.Room, true
+	case *chat.StreamResponse_MessageDeleted:
+		return evt.MessageDeleted.Room, true
+	case *chat.StreamResponse_MessageReaction:
+		return evt.MessageReaction.Room, true
+	case *chat.StreamResponse_FileAttachment:
+		return evt.FileAttachment.Room, true
+	case *chat.StreamResponse_ServerAnnouncement:
+		return evt.ServerAnnouncement.Room, evt.ServerAnnouncement.Room != ""
+	}
+	return "", false
+}
+
+// 条件に一致するイベントのうち新しいものから最大limit件を古い順に残す関数
+func tailHistory(events []*chat.StreamResponse, rooms roomFilter, since time.Time, limit int) []*chat.StreamResponse {
+	var out []*chat.StreamResponse
+	for _, res := range events {
+		if matchHistory(res, rooms, since) {
+			out = append(out, res)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+// シーケンス番号がseqより大きいイベントを古いものから最大limit件残す関数
+func afterSequence(events []*chat.StreamResponse, seq uint64, limit int) []*chat.StreamResponse {
+	var out []*chat.StreamResponse
+	for _, res := range events {
+		if res.Sequence > seq {
+			out = append(out, res)
+		}
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// 直近のイベントを固定長のリングバッファで保持するメモリ上の実装
+type ringStore struct {
+	buf         []*chat.StreamResponse
+	start, size int
+	mtx         sync.RWMutex
+}
+
+// 最大capacity件のイベントを保持するringStoreを生成する関数
+func newRingStore(capacity int) *ringStore {
+	if capacity <= 0 {
+		capacity = defaultHistorySize
+	}
+	return &ringStore{buf: make([]*chat.StreamResponse, capacity)}
+}
+
+func (r *ringStore) Append(res *chat.StreamResponse) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// バッファが一杯のときは最も古いイベントを上書き
+	r.buf[(r.start+r.size)%len(r.buf)] = res
+	if r.size < len(r.buf) {
+		r.size++
+	} else {
+		r.start = (r.start + 1) % len(r.buf)
+	}
+	return nil
+}
+
+func (r *ringStore) History(rooms roomFilter, since time.Time, limit int) ([]*chat.StreamResponse, error) {
+	return tailHistory(r.events(), rooms, since, limit), nil
+}
+
+func (r *ringStore) After(seq uint64, limit int) ([]*chat.StreamResponse, error) {
+	return afterSequence(r.events(), seq, limit), nil
+}
+
+// 保持しているイベントを古い順に並べて返すメソッド
+func (r *ringStore) events() []*chat.StreamResponse {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	events := make([]*chat.StreamResponse, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		events = append(events, r.buf[(r.start+i)%len(r.buf)])
+	}
+	return events
+}
+
+func (r *ringStore) Close() error { return nil }
+
+// イベントを1行1件のJSONとしてファイルに追記していく実装、サーバを再起動しても履歴が残る
+// 直近のイベントはメモリ上にも保持し、その範囲で足りる問い合わせではファイルを読まない
+// ファイルがmaxBytesを超えると".1"を付けた名前に移して新しいファイルに書き始め、それより前の履歴は捨てる
+type fileStore struct {
+	path     string
+	maxBytes int64
+	// 追記用のハンドルと、現在のファイルに書いたバイト数
+	f    *os.File
+	size int64
+	// 読み取り用のハンドル、curは現在のファイル、prevはローテーションした1つ前のファイル(無いときはnil)
+	cur, prev *os.File
+	// ファイルに書かれている全てのイベントの位置と検索条件に使う属性、古い順
+	index []historyEntry
+	// ファイルの末尾の直近のイベント
+	tail *ringStore
+	// 追記とindexを守るロック、ファイルの読み取りの間は保持しない
+	mtx sync.Mutex
+	// 読み取り中のハンドルがローテーションで閉じられないようにするロック、mtxより後に取る
+	readMtx sync.RWMutex
+}
+
+// ファイルに書かれた1件のイベントの位置
+type historyEntry struct {
+	historyKey
+	file *os.File
+	off  int64
+	n    int
+}
+
+// pathのファイルを追記モードで開いてfileStoreを生成する関数、ファイルが無いときは作成する
+// 既にあるファイルの位置を読み込み、末尾からtailSize件をメモリ上に保持する、maxBytesが0以下のときはローテーションしない
+func newFileStore(path string, tailSize int, maxBytes int64) (*fileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to open history file")
+	}
+	fs := &fileStore{path: path, maxBytes: maxBytes, f: f, tail: newRingStore(tailSize)}
+
+	if prev, err := os.Open(path + ".1"); err == nil {
+		fs.prev = prev
+		if _, err := fs.load(prev); err != nil {
+			fs.Close()
+			return nil, err
+		}
+	}
+	if fs.cur, err = os.Open(path); err != nil {
+		fs.Close()
+		return nil, errors.WithMessage(err, "unable to open history file")
+	}
+	if fs.size, err = fs.load(fs.cur); err != nil {
+		fs.Close()
+		return nil, err
+	}
+	return fs, nil
+}
+
+// ファイルを先頭から読み、イベントの位置をindexに、イベントをtailに追加するメソッド、読んだバイト数を返す
+func (fs *fileStore) load(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	var off int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			res := new(chat.StreamResponse)
+			if uerr := protojson.Unmarshal(line, res); uerr != nil {
+				// 書き込み途中で終了した行などは読み飛ばす
+				logger.Debug("skipping malformed history entry", "component", "store", "err", uerr)
+			} else {
+				fs.index = append(fs.index, historyEntry{historyKey: keyOf(res), file: f, off: off, n: len(line)})
+				fs.tail.Append(res)
+			}
+		}
+		off += int64(len(line))
+		if err == io.EOF {
+			return off, nil
+		}
+		if err != nil {
+			return off, errors.WithMessage(err, "unable to read history file")
+		}
+	}
+}
+
+func (fs *fileStore) Append(res *chat.StreamResponse) error {
+	b, err := protojson.Marshal(res)
+	if err != nil {
+		return errors.WithMessage(err, "unable to encode history entry")
+	}
+	b = append(b, '\n')
+
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	if fs.maxBytes > 0 && fs.size > 0 && fs.size+int64(len(b)) > fs.maxBytes {
+		if err := fs.rotate(); err != nil {
+			return err
+		}
+	}
+	if _, err = fs.f.Write(b); err != nil {
+		return errors.WithMessage(err, "unable to write history entry")
+	}
+	fs.index = append(fs.index, historyEntry{historyKey: keyOf(res), file: fs.cur, off: fs.size, n: len(b)})
+	fs.size += int64(len(b))
+	fs.tail.Append(res)
+	return nil
+}
+
+// 現在のファイルを".1"を付けた名前に移し、新しいファイルに書き始めるメソッド、それまでの".1"のファイルは削除する
+// 名前の変更とハンドルの付け替えだけで、ファイルの中身はコピーしない
+func (fs *fileStore) rotate() error {
+	fs.readMtx.Lock()
+	defer fs.readMtx.Unlock()
+
+	if err := os.Rename(fs.path, fs.path+".1"); err != nil {
+		return errors.WithMessage(err, "unable to rotate history file")
+	}
+	f, err := os.OpenFile(fs.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.WithMessage(err, "unable to open history file")
+	}
+	cur, err := os.Open(fs.path)
+	if err != nil {
+		f.Close()
+		return errors.WithMessage(err, "unable to open history file")
+	}
+
+	// 捨てたファイルにあったイベントの位置を取り除く
+	dropped := fs.prev
+	n := 0
+	for n < len(fs.index) && fs.index[n].file == dropped {
+		n++
+	}
+	fs.index = append([]historyEntry(nil), fs.index[n:]...)
+	if dropped != nil {
+		dropped.Close()
+	}
+
+	fs.f.Close()
+	fs.f, fs.size = f, 0
+	fs.prev, fs.cur = fs.cur, cur
+	return nil
+}
+
+func (fs *fileStore) History(rooms roomFilter, since time.Time, limit int) ([]*chat.StreamResponse, error) {
+	fs.mtx.Lock()
+	// ファイル全体がメモリ上にあるか、直近のイベントだけでlimit件に達したときはファイルを読まない
+	events := tailHistory(fs.tail.events(), rooms, since, limit)
+	if len(fs.index) <= len(fs.tail.buf) || (limit > 0 && len(events) == limit) {
+		fs.mtx.Unlock()
+		return events, nil
+	}
+
+	var found []historyEntry
+	for i := len(fs.index) - 1; i >= 0 && (limit <= 0 || len(found) < limit); i-- {
+		if fs.index[i].matches(rooms, since) {
+			found = append(found, fs.index[i])
+		}
+	}
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+	return fs.read(found)
+}
+
+func (fs *fileStore) After(seq uint64, limit int) ([]*chat.StreamResponse, error) {
+	fs.mtx.Lock()
+	// メモリ上の最も古いイベントがseq以前なら、それより後のイベントは全てメモリ上にある
+	tail := fs.tail.events()
+	if len(fs.index) <= len(fs.tail.buf) || (len(tail) > 0 && tail[0].Sequence <= seq) {
+		fs.mtx.Unlock()
+		return afterSequence(tail, seq, limit), nil
+	}
+
+	var found []historyEntry
+	for _, e := range fs.index {
+		if e.seq > seq {
+			found = append(found, e)
+		}
+		if limit > 0 && len(found) == limit {
+			break
+		}
+	}
+	return fs.read(found)
+}
+
+// indexから選んだイベントをファイルから読むメソッド、fs.mtxを保持した状態で呼び、読み取りの前にfs.mtxを解放する
+// 読み取りの間は追記を止めず、ローテーションだけを待たせる
+func (fs *fileStore) read(entries []historyEntry) ([]*chat.StreamResponse, error) {
+	fs.readMtx.RLock()
+	fs.mtx.Unlock()
+	defer fs.readMtx.RUnlock()
+
+	events := make([]*chat.StreamResponse, 0, len(entries))
+	for _, e := range entries {
+		line := make([]byte, e.n)
+		if _, err := e.file.ReadAt(line, e.off); err != nil {
+			return nil, errors.WithMessage(err, "unable to read history file")
+		}
+		res := new(chat.StreamResponse)
+		if err := protojson.Unmarshal(line, res); err != nil {
+			return nil, errors.WithMessage(err, "unable to decode history entry")
+		}
+		events = append(events, res)
+	}
+	return events, nil
+}
+
+func (fs *fileStore) Close() error {
+	fs.mtx.Lock()
+	defer fs.mtx.Unlock()
+
+	for _, f := range []*os.File{fs.cur, fs.prev} {
+		if f != nil {
+			f.Close()
+		}
+	}
+	return fs.f.Close()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func storedMessage(seq uint64, room, msg string) *chat.StreamResponse {
+	return &chat.StreamResponse{
+		Timestamp: timestamppb.Now(),
+		Sequence:  seq,
+		Event: &chat.StreamResponse_ClientMessage{
+			ClientMessage: &chat.StreamResponse_Message{Name: "alice", Message: msg, Room: room},
+		},
+	}
+}
+
+func sequences(events []*chat.StreamResponse) []uint64 {
+	var out []uint64
+	for _, res := range events {
+		out = append(out, res.Sequence)
+	}
+	return out
+}
+
+func TestFileStoreTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	fs, err := newFileStore(path, 3, 0)
+	require.NoError(t, err)
+	for seq := uint64(1); seq <= 6; seq++ {
+		room := defaultRoom
+		if seq%2 == 0 {
+			room = "go"
+		}
+		require.NoError(t, fs.Append(storedMessage(seq, room, "hi")))
+	}
+	require.NoError(t, fs.Close())
+
+	// 再び開いたときは全てのイベントの位置と、末尾の3件だけがメモリ上に残る
+	fs, err = newFileStore(path, 3, 0)
+	require.NoError(t, err)
+	require.Len(t, fs.index, 6)
+	require.Equal(t, []uint64{4, 5, 6}, sequences(fs.tail.events()))
+
+	// メモリ上に無い範囲は位置を使ってファイルから読む
+	events, err := fs.History(roomFilter{room: defaultRoom}, time.Time{}, 3)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 3, 5}, sequences(events))
+	events, err = fs.After(2, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3, 4, 5, 6}, sequences(events))
+
+	// 読み取り用のハンドルを閉じても、メモリ上の直近のイベントで足りる問い合わせには答えられる
+	require.NoError(t, fs.cur.Close())
+
+	events, err = fs.History(roomFilter{}, time.Time{}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5, 6}, sequences(events))
+	events, err = fs.After(4, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5, 6}, sequences(events))
+
+	_, err = fs.History(roomFilter{room: defaultRoom}, time.Time{}, 3)
+	require.Error(t, err)
+	_, err = fs.After(2, 0)
+	require.Error(t, err)
+	require.NoError(t, fs.f.Close())
+}
+
+func TestFileStoreRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	// 1行の長さが揃うよう、時刻を固定する
+	at := timestamppb.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	message := func(seq uint64) *chat.StreamResponse {
+		res := storedMessage(seq, defaultRoom, "hi")
+		res.Timestamp = at
+		return res
+	}
+	line, err := protojson.Marshal(message(1))
+	require.NoError(t, err)
+	// 1つのファイルに3件まで書ける大きさにする
+	maxBytes := int64(3*(len(line)+1) + 1)
+
+	fs, err := newFileStore(path, 2, maxBytes)
+	require.NoError(t, err)
+	for seq := uint64(1); seq <= 8; seq++ {
+		require.NoError(t, fs.Append(message(seq)))
+	}
+
+	// 2回ローテーションし、最初のファイルの3件は捨てる
+	require.FileExists(t, path+".1")
+	events, err := fs.History(roomFilter{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4, 5, 6, 7, 8}, sequences(events))
+	require.NoError(t, fs.Close())
+
+	// 再び開いたときは".1"のファイルも読む
+	fs, err = newFileStore(path, 2, maxBytes)
+	require.NoError(t, err)
+	t.Cleanup(func() { fs.Close() })
+	events, err = fs.After(3, 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4, 5, 6, 7, 8}, sequences(events))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"grpc-chat/tlsconf"
+
+	"github.com/pkg/errors"
+)
+
+// TLSの設定、サーバとクライアント、ボットで共有するのでtlsconfパッケージで定義する
+type tlsConfig = tlsconf.Config
+
+// "gen-certs"サブコマンド、ローカルで使うための自己署名CAとサーバ・クライアントの証明書を生成する
+func genCerts(args []string) error {
+	fs := flag.NewFlagSet("gen-certs", flag.ExitOnError)
+	out := fs.String("out", "certs", "the directory to write the certificates and keys to")
+	hosts := fs.String("hosts", "localhost,127.0.0.1,::1,0.0.0.0", "comma separated host names and IPs the server certificate is valid for")
+	validFor := fs.Duration("valid-for", 365*24*time.Hour, "how long the certificates are valid")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return errors.WithMessage(err, "unable to create output directory")
+	}
+
+	notAfter := time.Now().Add(*validFor)
+
+	ca := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "grpc-chat local CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caCert, caKey, err := writeCert(*out, "ca", ca, nil, nil, notAfter)
+	if err != nil {
+		return err
+	}
+
+	srv := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "grpc-chat server"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range strings.Split(*hosts, ",") {
+		if h = strings.TrimSpace(h); h == "" {
+			continue
+		}
+		if ip := net.ParseIP(h); ip != nil {
+			srv.IPAddresses = append(srv.IPAddresses, ip)
+		} else {
+			srv.DNSNames = append(srv.DNSNames, h)
+		}
+	}
+	if _, _, err := writeCert(*out, "server", srv, caCert, caKey, notAfter); err != nil {
+		return err
+	}
+
+	cli := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "grpc-chat client"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if _, _, err := writeCert(*out, "client", cli, caCert, caKey, notAfter); err != nil {
+		return err
+	}
+
+	logger.Info("wrote ca.pem, server.pem and client.pem (with their keys)", "dir", *out)
+	return nil
+}
+
+// 鍵を生成してテンプレートの証明書に署名し、<name>.pemと<name>-key.pemに書き出す関数、parentがnilのときは自己署名
+func writeCert(dir, name string, tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "unable to generate key")
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "unable to generate serial number")
+	}
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = notAfter
+
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "unable to create %s certificate", name)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writePEM(filepath.Join(dir, name+".pem"), "CERTIFICATE", der, 0o644); err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", keyDER, 0o600); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func writePEM(path, typ string, der []byte, perm os.FileMode) error {
+	b := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	return errors.WithMessagef(os.WriteFile(path, b, perm), "unable to write %s", path)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gen-certsで証明書を生成し、そのディレクトリの中のファイルのパスを返す関数を返す
+func genTestCerts(t *testing.T) func(name string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, genCerts([]string{"-out", dir, "-hosts", "bufnet"}))
+	return func(name string) string { return filepath.Join(dir, name) }
+}
+
+// TLSの設定でサーバに接続してログインを試みる関数
+func tlsLogin(t *testing.T, ts *testServer, cfg tlsConfig) error {
+	t.Helper()
+
+	creds, err := cfg.ClientCredentials("bufnet")
+	require.NoError(t, err)
+	conn, err := grpc.Dial("bufnet", ts.dialer(), grpc.WithTransportCredentials(creds))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	_, err = chat.NewChatClient(conn).Login(ctx, &chat.LoginRequest{Name: "alice", Password: testPassword})
+	return err
+}
+
+func TestTLS(t *testing.T) {
+	file := genTestCerts(t)
+	creds, err := tlsConfig{CertFile: file("server.pem"), KeyFile: file("server-key.pem")}.ServerCredentials()
+	require.NoError(t, err)
+	ts := startServer(t, func(s *server) { s.Creds = creds })
+
+	require.NoError(t, tlsLogin(t, ts, tlsConfig{CAFile: file("ca.pem")}))
+}
+
+func TestMutualTLS(t *testing.T) {
+	file := genTestCerts(t)
+	creds, err := tlsConfig{
+		CertFile: file("server.pem"),
+		KeyFile:  file("server-key.pem"),
+		CAFile:   file("ca.pem"),
+		Mutual:   true,
+	}.ServerCredentials()
+	require.NoError(t, err)
+	ts := startServer(t, func(s *server) { s.Creds = creds })
+
+	// クライアント証明書が無い接続は拒否される
+	err = tlsLogin(t, ts, tlsConfig{CAFile: file("ca.pem")})
+	require.Error(t, err)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	require.NoError(t, tlsLogin(t, ts, tlsConfig{
+		CertFile: file("client.pem"),
+		KeyFile:  file("client-key.pem"),
+		CAFile:   file("ca.pem"),
+		Mutual:   true,
+	}))
+}
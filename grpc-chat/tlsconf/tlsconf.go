@@ -0,0 +1,118 @@
+package tlsconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSの設定用パッケージ
+// サーバと端末のクライアント、cmd/以下のボットが同じフラグと同じ検証の手順を使うよう共有する
+
+// Config holds the TLS flags shared by the server, the client and bots
+// CertFileとKeyFileは自身の証明書、CAFileは相手の証明書を検証するためのCA証明書
+type Config struct {
+	CertFile, KeyFile, CAFile string
+	// クライアント証明書による相互認証(mTLS)を行う
+	Mutual bool
+}
+
+// サーバ側の認証情報を生成するメソッド、証明書が指定されていないときはnilを返し平文で通信する
+func (t Config) ServerCredentials() (credentials.TransportCredentials, error) {
+	if t.CertFile == "" && t.KeyFile == "" {
+		if t.Mutual {
+			return nil, errors.New("-mtls requires -tls-cert and -tls-key")
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to load server certificate")
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	// 相互認証のときはCA証明書で署名されたクライアント証明書を必須にする
+	if t.Mutual {
+		if t.CAFile == "" {
+			return nil, errors.New("-mtls requires -tls-ca to verify client certificates")
+		}
+		pool, err := loadCertPool(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// クライアント側の認証情報を生成するメソッド、TLSの設定が何も無いときはnilを返し平文で通信する
+func (t Config) ClientCredentials(host string) (credentials.TransportCredentials, error) {
+	if t.CertFile == "" && t.KeyFile == "" && t.CAFile == "" && !t.Mutual {
+		return nil, nil
+	}
+
+	serverName, _, err := net.SplitHostPort(host)
+	if err != nil {
+		serverName = host
+	}
+
+	cfg := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	// CA証明書が指定されていないときはシステムの証明書でサーバを検証する
+	if t.CAFile != "" {
+		pool, err := loadCertPool(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.Mutual || t.CertFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, errors.New("-mtls requires -tls-cert and -tls-key for the client certificate")
+		}
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "unable to load client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// PEM形式のCA証明書を読み込む関数
+func loadCertPool(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to read CA certificate")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// RegisterFlags は"-tls-cert"、"-tls-key"、"-tls-ca"、"-mtls"をfsに登録し、解析した値をtに格納する
+func (t *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&t.CertFile, "tls-cert", "", "the PEM certificate presented by this server or client")
+	fs.StringVar(&t.KeyFile, "tls-key", "", "the PEM private key for -tls-cert")
+	fs.StringVar(&t.CAFile, "tls-ca", "", "the PEM CA certificate used to verify the other side")
+	fs.BoolVar(&t.Mutual, "mtls", false, "require client certificates (server) or present one (client)")
+}
@@ -0,0 +1,24 @@
+package tlsconf
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	var cfg Config
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg.RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-tls-ca", "ca.pem", "-mtls", "-tls-cert", "client.pem", "-tls-key", "client-key.pem"}))
+	require.Equal(t, Config{CertFile: "client.pem", KeyFile: "client-key.pem", CAFile: "ca.pem", Mutual: true}, cfg)
+
+	// 何も指定しないときは平文で通信する
+	creds, err := Config{}.ClientCredentials("localhost:6262")
+	require.NoError(t, err)
+	require.Nil(t, creds)
+	// 相互認証にはクライアント証明書が要る
+	_, err = Config{Mutual: true}.ClientCredentials("localhost:6262")
+	require.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const minSecretKeySize = 32
+
+// HMACMaker is a token maker that signs a JSON payload with HMAC-SHA256;トークンは"<ペイロード>.<署名>"をそれぞれbase64urlでエンコードした形式
+type HMACMaker struct {
+	secretKey []byte
+}
+
+// 新しいHMACMakerインスタンスを作成;秘密キーの長さが最小要件を満たしていない場合、エラーを返す
+func NewHMACMaker(secretKey string) (*HMACMaker, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+	return &HMACMaker{[]byte(secretKey)}, nil
+}
+
+// 特定のユーザ名と有効期間で新しいトークンを作成する
+func (maker *HMACMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, duration)
+	if err != nil {
+		return "", nil, err
+	}
+	token, err := maker.sign(payload)
+	return token, payload, err
+}
+
+// 同じセッションID・ユーザ名のまま有効期限を延長したトークンを作成する
+func (maker *HMACMaker) RenewToken(payload *Payload, duration time.Duration) (string, *Payload, error) {
+	now := time.Now()
+	renewed := &Payload{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		IssuedAt:  now,
+		ExpiredAt: now.Add(duration),
+	}
+	token, err := maker.sign(renewed)
+	return token, renewed, err
+}
+
+// 提供されたトークンの署名と有効期限を検証し、ペイロードを返す
+func (maker *HMACMaker) VerifyToken(token string) (*Payload, error) {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	// 署名の比較は時間差攻撃を避けるためhmac.Equalを使う
+	if !hmac.Equal(got, maker.mac(body)) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	payload := new(Payload)
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := payload.Valid(); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// ペイロードをエンコードして署名を付与する
+func (maker *HMACMaker) sign(payload *Payload) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(raw)
+	return body + "." + base64.RawURLEncoding.EncodeToString(maker.mac(body)), nil
+}
+
+func (maker *HMACMaker) mac(body string) []byte {
+	h := hmac.New(sha256.New, maker.secretKey)
+	h.Write([]byte(body))
+	return h.Sum(nil)
+}
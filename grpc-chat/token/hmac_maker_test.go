@@ -0,0 +1,93 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestHMACMaker(t *testing.T) {
+	maker, err := NewHMACMaker(testSecret)
+	require.NoError(t, err)
+
+	username := "alice"
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestExpiredHMACToken(t *testing.T) {
+	maker, err := NewHMACMaker(testSecret)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken("alice", -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestTamperedHMACToken(t *testing.T) {
+	maker, err := NewHMACMaker(testSecret)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+
+	// 別の鍵で署名されたトークンは拒否される
+	other, err := NewHMACMaker(strings.Repeat("x", minSecretKeySize))
+	require.NoError(t, err)
+	_, err = other.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// ペイロードを書き換えたトークンは拒否される
+	forged, _, err := other.CreateToken("mallory", time.Minute)
+	require.NoError(t, err)
+	body, _, _ := strings.Cut(forged, ".")
+	_, sig, _ := strings.Cut(token, ".")
+	_, err = maker.VerifyToken(body + "." + sig)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestRenewHMACToken(t *testing.T) {
+	maker, err := NewHMACMaker(testSecret)
+	require.NoError(t, err)
+
+	_, payload, err := maker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+
+	token, renewed, err := maker.RenewToken(payload, time.Hour)
+	require.NoError(t, err)
+
+	renewed, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, renewed.ID)
+	require.Equal(t, payload.Username, renewed.Username)
+	require.True(t, renewed.ExpiredAt.After(payload.ExpiredAt))
+}
+
+func TestShortSecretKey(t *testing.T) {
+	_, err := NewHMACMaker("short")
+	require.Error(t, err)
+}
@@ -0,0 +1,17 @@
+package token
+
+import (
+	"time"
+)
+
+// トークン作成のメソッドのインターフェース用ファイル
+
+// Maker is an interface for managing chat session tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username and duration
+	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+	// RenewToken creates a new token for the same session as the payload with a new expiry
+	RenewToken(payload *Payload, duration time.Duration) (string, *Payload, error)
+	// VerifyToken checks if the token is valid or not
+	VerifyToken(token string) (*Payload, error)
+}
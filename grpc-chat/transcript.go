@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"golang.org/x/term"
+)
+
+// 編集やリアクションに備えて覚えておく表示済みのメッセージの件数
+const maxShownMessages = 500
+
+// 表示したメッセージを覚えておき、編集・削除・リアクションがあったときにその行を描き直す
+// ログの出力先にすると他のログの行数も数えられ、端末に出力しているときは元の行をその場で書き換える
+type transcript struct {
+	out io.Writer
+	// 元の行を書き換えられる端末のファイル、nilのときは描き直した行を末尾に追加する
+	tty *os.File
+	// 標準入力も端末のときは、入力した行も端末の行数に含める
+	echo bool
+	// これまでに出力した行数
+	lines int
+	msgs  map[string]*shownMessage
+	order []string
+	mtx   sync.Mutex
+}
+
+// 出力済みの行をその場で書き換えられる出力先、端末UIのメッセージ欄など
+type lineRewriter interface {
+	// RewriteLine はn行目(最初の行を0とする)をtextに書き換え、書き換えられなかったときはfalseを返す
+	RewriteLine(n int, text string) bool
+}
+
+// 表示済みのメッセージとその現在の状態
+type shownMessage struct {
+	line            int
+	ts              time.Time
+	id, name, room  string
+	text            string
+	edited, deleted bool
+	reactions       map[string][]string
+}
+
+// outに出力するtranscriptを生成する関数、描き直した行は末尾に追加する
+func newTranscript(out io.Writer) *transcript {
+	return &transcript{out: out, msgs: make(map[string]*shownMessage)}
+}
+
+// 標準エラー出力に出力するtranscriptを生成してログの出力先にする関数、端末のときは元の行を書き換える
+func terminalTranscript() *transcript {
+	t := newTranscript(os.Stderr)
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		t.tty = os.Stderr
+		t.echo = term.IsTerminal(int(os.Stdin.Fd()))
+	}
+	log.SetOutput(t)
+	return t
+}
+
+// logパッケージなどからの出力を行数を数えながら書き込むメソッド
+func (t *transcript) Write(p []byte) (int, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.lines += strings.Count(string(p), "\n")
+	return t.out.Write(p)
+}
+
+// 端末で入力された1行を行数に含めるメソッド
+func (t *transcript) inputLine() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.echo {
+		t.lines++
+	}
+}
+
+// 新しいメッセージを表示して覚えておくメソッド
+func (t *transcript) message(ts time.Time, msg *chat.StreamResponse_Message) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	m := &shownMessage{ts: ts, id: msg.Id, name: msg.Name, room: msg.Room, text: msg.Message, reactions: make(map[string][]string)}
+	if msg.Id != "" {
+		if _, ok := t.msgs[msg.Id]; !ok {
+			t.order = append(t.order, msg.Id)
+		}
+		t.msgs[msg.Id] = m
+		for len(t.order) > maxShownMessages {
+			delete(t.msgs, t.order[0])
+			t.order = t.order[1:]
+		}
+	}
+	t.println(m)
+}
+
+// 編集されたメッセージを描き直すメソッド
+func (t *transcript) edited(evt *chat.StreamResponse_Edited) {
+	t.update(evt.Id, func(m *shownMessage) {
+		m.text, m.edited = evt.Message, true
+	})
+}
+
+// 削除されたメッセージを描き直すメソッド
+func (t *transcript) deleted(evt *chat.StreamResponse_Deleted) {
+	t.update(evt.Id, func(m *shownMessage) {
+		m.text, m.deleted = "", true
+	})
+}
+
+// リアクションが付いた、または取り消されたメッセージを描き直すメソッド
+func (t *transcript) reacted(evt *chat.StreamResponse_Reacted) {
+	t.update(evt.Id, func(m *shownMessage) {
+		names := m.reactions[evt.Emoji]
+		for i, n := range names {
+			if n == evt.Name {
+				names = append(names[:i], names[i+1:]...)
+				break
+			}
+		}
+		if !evt.Removed {
+			names = append(names, evt.Name)
+		}
+		if len(names) == 0 {
+			delete(m.reactions, evt.Emoji)
+		} else {
+			m.reactions[evt.Emoji] = names
+		}
+	})
+}
+
+// 覚えているメッセージを変更して描き直すメソッド、覚えていないメッセージは無視する
+func (t *transcript) update(id string, change func(*shownMessage)) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	m, ok := t.msgs[id]
+	if !ok {
+		return
+	}
+	change(m)
+
+	if rw, ok := t.out.(lineRewriter); ok && rw.RewriteLine(m.line, m.render()) {
+		return
+	}
+	// 元の行がまだ画面内にあるときはカーソルを移動して書き換え、元の位置に戻す
+	if up := t.lines - m.line; t.tty != nil && up > 0 && up < t.height() {
+		fmt.Fprintf(t.out, "\x1b7\x1b[%dA\r\x1b[2K%s\x1b8", up, m.render())
+		return
+	}
+	t.println(m)
+}
+
+// メッセージを新しい行として出力するメソッド、mtxを取得した状態で呼び出す
+func (t *transcript) println(m *shownMessage) {
+	m.line = t.lines
+	fmt.Fprintln(t.out, m.render())
+	t.lines++
+}
+
+// 端末の高さを返すメソッド、取得できないときは書き換えない
+func (t *transcript) height() int {
+	_, h, err := term.GetSize(int(t.tty.Fd()))
+	if err != nil {
+		return 0
+	}
+	return h
+}
+
+// メッセージを1行の文字列にするメソッド
+func (m *shownMessage) render() string {
+	text := m.text
+	switch {
+	case m.deleted:
+		text = "(message deleted)"
+	case m.edited:
+		text += " (edited)"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s: %s", m.ts.Format(timeFormat), roomName(m.room, m.name), text)
+
+	if len(m.reactions) > 0 && !m.deleted {
+		emojis := make([]string, 0, len(m.reactions))
+		for emoji := range m.reactions {
+			emojis = append(emojis, emoji)
+		}
+		sort.Strings(emojis)
+		for _, emoji := range emojis {
+			fmt.Fprintf(&b, " %s×%d", emoji, len(m.reactions[emoji]))
+		}
+	}
+	if m.id != "" {
+		fmt.Fprintf(&b, "  <%s>", m.id)
+	}
+	return b.String()
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const (
+	// 右側のユーザとルームの一覧の幅、端末の幅がこの2倍に満たないときは表示しない
+	sidebarWidth = 24
+	// メッセージ欄に保持する行数の上限
+	maxScrollback = 5000
+	// ユーザとルームの一覧を取り直す間隔
+	sidebarInterval = 5 * time.Second
+)
+
+// 発言者名の色、名前ごとに同じ色になるよう名前のハッシュで選ぶ
+var namePalette = []tcell.Color{
+	tcell.ColorAqua, tcell.ColorLime, tcell.ColorYellow, tcell.ColorFuchsia,
+	tcell.ColorOrange, tcell.ColorDodgerBlue, tcell.ColorSpringGreen, tcell.ColorHotPink,
+}
+
+// 表示する行の"[時刻] #ルーム 名前: "の名前の部分、ダイレクトメッセージのときは"送信者 -> 宛先"
+var lineName = regexp.MustCompile(`^\[[^\]]*\] (?:#\S+ )?([^:<>]+?): `)
+
+// 全画面の端末UI、メッセージ欄と入力欄、ユーザとルームの一覧を表示する
+// ログの出力先にして表示する行を受け取り、入力欄で確定した行をクライアントの入力として渡す
+type tui struct {
+	screen tcell.Screen
+	client *client
+	// メッセージ欄の行と、上限を超えて捨てた行数
+	lines   []string
+	dropped int
+	// 改行で終わっていない書きかけの行
+	partial string
+	// 最新の行から何行さかのぼって表示しているか、0のときは常に最新の行を表示する
+	scroll int
+	// 入力欄の内容とカーソルの位置
+	input  []rune
+	cursor int
+	// 送信した行の履歴と、上下キーで選んでいる位置
+	history []string
+	histPos int
+	// サイドバーに表示するユーザとルーム
+	users []*chat.ListUsersResponse_User
+	rooms []*chat.ListRoomsResponse_Room
+	mtx   sync.Mutex
+}
+
+// 画面の1文字分の内容
+type cell struct {
+	r     rune
+	style tcell.Style
+}
+
+// screenに全画面の端末UIを表示してRunを実行するメソッド、Ctrl+Cで終了する
+func (c *client) RunTUI(ctx context.Context, screen tcell.Screen) error {
+	if err := screen.Init(); err != nil {
+		return errors.WithMessage(err, "unable to initialize the terminal")
+	}
+	defer screen.Fini()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	t := &tui{screen: screen, client: c}
+	c.Transcript = newTranscript(t)
+	prev := log.Writer()
+	log.SetOutput(c.Transcript)
+	defer log.SetOutput(prev)
+
+	// 入力欄で確定した行は、標準入力の代わりにパイプを通してクライアントに渡す
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	c.Input = pr
+	submit := make(chan string, 100)
+	go func() {
+		for line := range submit {
+			fmt.Fprintln(pw, line)
+		}
+	}()
+
+	// 最初のイベントを受け取ったときと、ユーザの出入りやモデレーションがあったときはすぐに一覧を取り直す
+	refresh := make(chan struct{}, 1)
+	onEvent := c.OnEvent
+	seen := false
+	c.OnEvent = func(res *chat.StreamResponse) {
+		if onEvent != nil {
+			onEvent(res)
+		}
+		switch res.Event.(type) {
+		case *chat.StreamResponse_ClientLogin, *chat.StreamResponse_ClientLogout, *chat.StreamResponse_ModerationAction:
+		default:
+			if seen {
+				return
+			}
+		}
+		seen = true
+		select {
+		case refresh <- struct{}{}:
+		default:
+		}
+	}
+
+	go t.pollSidebar(ctx, refresh)
+	go t.handleEvents(cancel, submit)
+	t.draw()
+
+	return c.Run(ctx)
+}
+
+// 端末からのキー入力や画面サイズの変更を処理し続けるメソッド、画面を閉じると終了する
+func (t *tui) handleEvents(quit func(), submit chan<- string) {
+	defer close(submit)
+
+	for {
+		switch ev := t.screen.PollEvent().(type) {
+		case nil:
+			return
+		case *tcell.EventResize:
+			t.screen.Sync()
+			t.draw()
+		case *tcell.EventKey:
+			if line, ok := t.key(ev, quit); ok {
+				submit <- line
+			}
+		}
+	}
+}
+
+// 1回のキー入力を処理するメソッド、Enterで行を確定したときはその行を返す
+func (t *tui) key(ev *tcell.EventKey, quit func()) (line string, submitted bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	defer t.drawLocked()
+
+	_, h := t.screen.Size()
+	page := h / 2
+	if page < 1 {
+		page = 1
+	}
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		quit()
+	case tcell.KeyEnter:
+		line = string(t.input)
+		t.input, t.cursor = nil, 0
+		if strings.TrimSpace(line) == "" {
+			return "", false
+		}
+		t.history = append(t.history, line)
+		t.histPos = len(t.history)
+		t.scroll = 0
+		t.client.typing.stop()
+		return line, true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if t.cursor > 0 {
+			t.input = append(t.input[:t.cursor-1], t.input[t.cursor:]...)
+			t.cursor--
+		}
+	case tcell.KeyDelete:
+		if t.cursor < len(t.input) {
+			t.input = append(t.input[:t.cursor], t.input[t.cursor+1:]...)
+		}
+	case tcell.KeyLeft:
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case tcell.KeyRight:
+		if t.cursor < len(t.input) {
+			t.cursor++
+		}
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		t.cursor = 0
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		t.cursor = len(t.input)
+	case tcell.KeyCtrlU:
+		t.input, t.cursor = nil, 0
+	case tcell.KeyUp:
+		if t.histPos > 0 {
+			t.histPos--
+			t.input = []rune(t.history[t.histPos])
+			t.cursor = len(t.input)
+		}
+	case tcell.KeyDown:
+		if t.histPos < len(t.history) {
+			t.histPos++
+			t.input = nil
+			if t.histPos < len(t.history) {
+				t.input = []rune(t.history[t.histPos])
+			}
+			t.cursor = len(t.input)
+		}
+	case tcell.KeyPgUp:
+		t.scroll += page
+	case tcell.KeyPgDn:
+		t.scroll -= page
+	case tcell.KeyCtrlL:
+		t.screen.Sync()
+	case tcell.KeyRune:
+		t.input = append(t.input[:t.cursor], append([]rune{ev.Rune()}, t.input[t.cursor:]...)...)
+		t.cursor++
+	}
+	t.clampScroll()
+	// 入力欄に文字があれば入力中、空になったら入力をやめたとして知らせる
+	if len(t.input) > 0 {
+		t.client.typing.keystroke()
+	} else {
+		t.client.typing.stop()
+	}
+	return "", false
+}
+
+// ログやメッセージを行単位でメッセージ欄に追加するメソッド
+func (t *tui) Write(p []byte) (int, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	parts := strings.Split(t.partial+string(p), "\n")
+	t.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		t.lines = append(t.lines, line)
+		// 過去の行を読んでいる間は新しい行が届いても表示位置を動かさない
+		if t.scroll > 0 {
+			t.scroll++
+		}
+	}
+	if over := len(t.lines) - maxScrollback; over > 0 {
+		t.lines = append([]string(nil), t.lines[over:]...)
+		t.dropped += over
+	}
+	t.clampScroll()
+
+	t.drawLocked()
+	return len(p), nil
+}
+
+// n行目(最初の行を0とする)を書き換えるメソッド、既に捨てた行のときはfalseを返す
+func (t *tui) RewriteLine(n int, text string) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	i := n - t.dropped
+	if i < 0 || i >= len(t.lines) {
+		return false
+	}
+	t.lines[i] = text
+	t.drawLocked()
+	return true
+}
+
+// 一定の間隔と、refreshを受け取ったときにユーザとルームの一覧を取り直すメソッド
+func (t *tui) pollSidebar(ctx context.Context, refresh <-chan struct{}) {
+	ticker := time.NewTicker(sidebarInterval)
+	defer ticker.Stop()
+
+	for {
+		// ログインしてトークンを受け取るまではChatClientも使えない
+		if tkn := t.client.token(); tkn != "" {
+			rctx, cancel := context.WithTimeout(ctx, time.Second)
+			users, uerr := t.client.ChatClient.ListUsers(rctx, &chat.ListUsersRequest{Token: tkn})
+			rooms, rerr := t.client.ChatClient.ListRooms(rctx, &chat.ListRoomsRequest{Token: tkn})
+			cancel()
+
+			t.mtx.Lock()
+			if uerr == nil {
+				t.users = users.Users
+			}
+			if rerr == nil {
+				t.rooms = rooms.Rooms
+			}
+			t.drawLocked()
+			t.mtx.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-refresh:
+		}
+	}
+}
+
+// 画面全体を描き直すメソッド
+func (t *tui) draw() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.drawLocked()
+}
+
+// 画面全体を描き直すメソッド、mtxを取得した状態で呼び出す
+func (t *tui) drawLocked() {
+	t.screen.Clear()
+	w, h := t.screen.Size()
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	paneWidth := w
+	if w >= sidebarWidth*2 {
+		paneWidth = w - sidebarWidth - 1
+		for y := 0; y < h-1; y++ {
+			t.screen.SetContent(paneWidth, y, tcell.RuneVLine, nil, tcell.StyleDefault.Foreground(tcell.ColorGray))
+		}
+		t.drawSidebar(paneWidth+2, sidebarWidth-1, h-1)
+	}
+	t.drawMessages(paneWidth, h-1)
+	t.drawInput(w, h-1)
+
+	t.screen.Show()
+}
+
+// メッセージ欄を下から上へ描くメソッド、幅に収まらない行は折り返す
+func (t *tui) drawMessages(width, height int) {
+	if t.scroll > 0 && height > 0 {
+		height--
+		drawText(t.screen, 0, height, width, fmt.Sprintf("-- %d newer line(s), PgDn to scroll down --", t.scroll),
+			tcell.StyleDefault.Reverse(true))
+	}
+
+	y := height - 1
+	for i := len(t.lines) - 1 - t.scroll; i >= 0 && y >= 0; i-- {
+		rows := wrapCells(t.styledLine(t.lines[i]), width)
+		for j := len(rows) - 1; j >= 0 && y >= 0; j-- {
+			x := 0
+			for _, c := range rows[j] {
+				t.screen.SetContent(x, y, c.r, nil, c.style)
+				x += runewidth.RuneWidth(c.r)
+			}
+			y--
+		}
+	}
+}
+
+// 入力欄を描いてカーソルを置くメソッド、カーソルが見えるよう横にずらす
+func (t *tui) drawInput(width, y int) {
+	prompt := "> "
+	x := drawText(t.screen, 0, y, width, prompt, tcell.StyleDefault.Foreground(tcell.ColorGray))
+
+	// カーソルより前の文字の幅が収まるまで先頭から表示を省く
+	start, before := 0, runewidth.StringWidth(string(t.input[:t.cursor]))
+	for start < t.cursor && x+before >= width {
+		before -= runewidth.RuneWidth(t.input[start])
+		start++
+	}
+	drawText(t.screen, x, y, width-x, string(t.input[start:]), tcell.StyleDefault)
+	t.screen.ShowCursor(x+before, y)
+}
+
+// ユーザとルームの一覧を描くメソッド
+func (t *tui) drawSidebar(x, width, height int) {
+	y := 0
+	line := func(text string, style tcell.Style) {
+		if y < height {
+			drawText(t.screen, x, y, width, text, style)
+			y++
+		}
+	}
+
+	line(fmt.Sprintf("Users (%d)", len(t.users)), tcell.StyleDefault.Bold(true))
+	for _, u := range t.users {
+		mark, style := "●", tcell.StyleDefault.Foreground(nameColor(u.Name))
+		switch {
+		case !u.Connected:
+			mark, style = "○", style.Dim(true)
+		case u.Status == chat.ListUsersResponse_IDLE:
+			mark = "◐"
+		case u.Status == chat.ListUsersResponse_AWAY:
+			mark, style = "○", style.Dim(true)
+		}
+		if u.Name == t.client.Name {
+			style = style.Bold(true)
+		}
+		line(" "+mark+" "+u.Name, style)
+	}
+
+	line("", tcell.StyleDefault)
+	line("Rooms", tcell.StyleDefault.Bold(true))
+	for _, r := range t.rooms {
+		style := tcell.StyleDefault
+		for _, m := range r.Members {
+			if m == t.client.Name {
+				style = style.Bold(true)
+			}
+		}
+		line(fmt.Sprintf(" #%s (%d)", r.Name, len(r.Members)), style)
+	}
+}
+
+// メッセージ欄の1行を、発言者名に色を付けた文字の並びにするメソッド
+func (t *tui) styledLine(line string) []cell {
+	runes := []rune(line)
+	cells := make([]cell, len(runes))
+	for i, r := range runes {
+		cells[i] = cell{r: r, style: tcell.StyleDefault}
+	}
+
+	loc := lineName.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return cells
+	}
+	name := line[loc[2]:loc[3]]
+	sender, _, _ := strings.Cut(name, " -> ")
+	style := tcell.StyleDefault.Foreground(nameColor(sender))
+	if sender == t.client.Name {
+		style = style.Bold(true)
+	}
+	start, end := len([]rune(line[:loc[2]])), len([]rune(line[:loc[3]]))
+	for i := start; i < end; i++ {
+		cells[i].style = style
+	}
+	return cells
+}
+
+// 名前に対応する色を返す関数
+func nameColor(name string) tcell.Color {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return namePalette[h.Sum32()%uint32(len(namePalette))]
+}
+
+// 文字の並びを表示幅がwidthに収まる行に折り返す関数
+func wrapCells(cells []cell, width int) [][]cell {
+	if width <= 0 {
+		return nil
+	}
+	rows := [][]cell{nil}
+	x := 0
+	for _, c := range cells {
+		w := runewidth.RuneWidth(c.r)
+		if x+w > width {
+			rows = append(rows, nil)
+			x = 0
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], c)
+		x += w
+	}
+	return rows
+}
+
+// 表示幅width以内で文字列を描き、描き終えた位置を返す関数
+func drawText(screen tcell.Screen, x, y, width int, text string, style tcell.Style) int {
+	end := x + width
+	for _, r := range text {
+		w := runewidth.RuneWidth(r)
+		if x+w > end {
+			break
+		}
+		screen.SetContent(x, y, r, nil, style)
+		x += w
+	}
+	return x
+}
+
+// スクロールの位置を表示できる範囲に収めるメソッド、mtxを取得した状態で呼び出す
+func (t *tui) clampScroll() {
+	if t.scroll > len(t.lines)-1 {
+		t.scroll = len(t.lines) - 1
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+}
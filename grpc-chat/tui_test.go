@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// シミュレーション画面のx0からx1の列の文字を行ごとに連結して返す関数
+// GetContentsの戻り値は描画中に書き換えられるので、1文字ずつロックを取って読む
+func screenText(screen tcell.SimulationScreen, x0, x1 int) string {
+	w, h := screen.Size()
+	var b strings.Builder
+	for y := 0; y < h; y++ {
+		for x := x0; x < x1 && x < w; x++ {
+			r, _, _, _ := screen.GetContent(x, y)
+			b.WriteRune(r)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// 入力欄に1行を打ち込んでEnterを押す関数
+func typeLine(screen tcell.SimulationScreen, line string) {
+	for _, r := range line {
+		screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+	}
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+}
+
+func TestTUI(t *testing.T) {
+	ts := startServer(t)
+	bob := ts.startClient(t, "bob")
+
+	screen := tcell.NewSimulationScreen("")
+	alice := Client("bufnet", testPassword, "alice")
+	alice.DialOptions = []grpc.DialOption{ts.dialer()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- alice.RunTUI(ctx, screen) }()
+	require.Eventually(t, func() bool { return ts.isOnline("alice") }, testTimeout, 10*time.Millisecond)
+
+	contains := func(x0, x1 int, text string) func() bool {
+		return func() bool { return strings.Contains(screenText(screen, x0, x1), text) }
+	}
+
+	// 受け取ったメッセージはメッセージ欄に、ログイン中のユーザとルームはサイドバーに表示される
+	w, _ := screen.Size()
+	pane := w - sidebarWidth
+	bob.say(t, "hi alice")
+	require.Eventually(t, contains(0, pane, "bob: hi alice"), testTimeout, 10*time.Millisecond)
+	require.Eventually(t, contains(pane, w, "bob"), testTimeout, 10*time.Millisecond)
+	require.Eventually(t, contains(pane, w, "#"+defaultRoom+" (2)"), testTimeout, 10*time.Millisecond)
+
+	// 入力欄で確定した行は同じストリームで送信される
+	typeLine(screen, "hello bob")
+	res := bob.expect(t, "alice's message", messageFrom("alice", "hello bob"))
+	require.Eventually(t, contains(0, pane, "alice: hello bob"), testTimeout, 10*time.Millisecond)
+
+	// 編集されたメッセージは元の行が書き換えられる
+	id := res.GetClientMessage().Id
+	typeLine(screen, "/edit "+id+" hello again")
+	require.Eventually(t, contains(0, pane, "alice: hello again (edited)"), testTimeout, 10*time.Millisecond)
+	require.NotContains(t, screenText(screen, 0, pane), "alice: hello bob")
+
+	// Ctrl+Cでログアウトして終了する
+	screen.InjectKey(tcell.KeyCtrlC, 0, tcell.ModCtrl)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(testTimeout):
+		t.Fatal("the terminal UI did not stop")
+	}
+	bob.expect(t, "alice's logout", logoutOf("alice"))
+}
+
+func TestWrapCells(t *testing.T) {
+	cells := func(s string) []cell {
+		var out []cell
+		for _, r := range s {
+			out = append(out, cell{r: r})
+		}
+		return out
+	}
+	text := func(rows [][]cell) (out []string) {
+		for _, row := range rows {
+			var b strings.Builder
+			for _, c := range row {
+				b.WriteRune(c.r)
+			}
+			out = append(out, b.String())
+		}
+		return out
+	}
+
+	require.Equal(t, []string{"abcd", "ef"}, text(wrapCells(cells("abcdef"), 4)))
+	// 全角文字は2列として数える
+	require.Equal(t, []string{"あい", "う"}, text(wrapCells(cells("あいう"), 5)))
+	require.Equal(t, []string{""}, text(wrapCells(nil, 4)))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	chat "grpc-chat/protos"
+)
+
+// 最後の入力からこの時間が経つと入力をやめたとみなす
+const typingIdle = 3 * time.Second
+
+// 入力の操作から入力中の通知を送るかを判断する、入力を始めたときと、やめたときに1回ずつsendを呼ぶ
+type typingNotifier struct {
+	send func(start bool)
+	idle time.Duration
+
+	active bool
+	timer  *time.Timer
+	mtx    sync.Mutex
+}
+
+func newTypingNotifier(idle time.Duration, send func(start bool)) *typingNotifier {
+	return &typingNotifier{send: send, idle: idle}
+}
+
+// 入力があったことを知らせるメソッド、入力中でなければ開始を通知し、idleの間入力が無ければ終了を通知する
+func (n *typingNotifier) keystroke() {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if !n.active {
+		n.active = true
+		n.send(true)
+	}
+	if n.timer == nil {
+		n.timer = time.AfterFunc(n.idle, n.stop)
+	} else {
+		n.timer.Reset(n.idle)
+	}
+}
+
+// 入力をやめたこと(送信や入力欄の消去)を知らせるメソッド、入力中でなければ何もしない
+func (n *typingNotifier) stop() {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if n.timer != nil {
+		n.timer.Stop()
+	}
+	if n.active {
+		n.active = false
+		n.send(false)
+	}
+}
+
+// 入力中の通知のリクエストを生成する関数
+func typingRequest(start bool, room string) *chat.StreamRequest {
+	if start {
+		return &chat.StreamRequest{Room: room, Action: &chat.StreamRequest_StartTyping{StartTyping: new(chat.StreamRequest_TypingStarted)}}
+	}
+	return &chat.StreamRequest{Room: room, Action: &chat.StreamRequest_StopTyping{StopTyping: new(chat.StreamRequest_TypingStopped)}}
+}
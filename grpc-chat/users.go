@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	chat "grpc-chat/protos"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// 登録できるパスワードの最小の長さ
+	minPasswordLength = 8
+	// 登録できるパスワードの最大のバイト数、bcryptはこれより長いパスワードをハッシュ化できない
+	maxPasswordBytes = 72
+)
+
+var (
+	errUserExists     = errors.New("user already exists")
+	errBadCredentials = errors.New("name or password is incorrect")
+)
+
+// ユーザ名とbcryptでハッシュ化したパスワードを保持し、JSONファイルに保存するユーザ登録簿
+type userRegistry struct {
+	path string
+	// ユーザ名をキーとしてパスワードのハッシュを保持
+	users map[string]string
+	mtx   sync.RWMutex
+}
+
+// pathのJSONファイルからユーザ登録簿を読み込む関数、ファイルが無いときは空の登録簿を返し、最初の登録時に作成する
+func loadUsers(path string) (*userRegistry, error) {
+	r := &userRegistry{path: path, users: make(map[string]string)}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	} else if err != nil {
+		return nil, errors.WithMessage(err, "unable to read user file")
+	}
+
+	if err := json.Unmarshal(b, &r.users); err != nil {
+		return nil, errors.WithMessage(err, "unable to parse user file")
+	}
+	return r, nil
+}
+
+// ユーザを登録してファイルに保存するメソッド
+func (r *userRegistry) Register(name, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, ok := r.users[name]; ok {
+		return errUserExists
+	}
+	r.users[name] = hash
+
+	if err := r.save(); err != nil {
+		delete(r.users, name)
+		return err
+	}
+	return nil
+}
+
+// ユーザ名とパスワードの組を検証するメソッド
+// 登録されていないユーザでもダミーのハッシュと比べ、応答時間からアカウントの有無が分からないようにする
+func (r *userRegistry) Check(name, password string) error {
+	r.mtx.RLock()
+	hash, ok := r.users[name]
+	r.mtx.RUnlock()
+
+	if !ok {
+		hash = dummyHash()
+	}
+	if checkPassword(password, hash) != nil || !ok {
+		return errBadCredentials
+	}
+	return nil
+}
+
+// 登録されていないユーザの検証に使うハッシュ、初めて使うときに一度だけ生成する
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := hashPassword("not a registered user's password")
+	return hash
+})
+
+// 登録簿をファイルに書き出すメソッド、書き込み途中で終了しても壊れないよう一時ファイルから置き換える
+func (r *userRegistry) save() error {
+	b, err := json.MarshalIndent(r.users, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "unable to encode user file")
+	}
+	return errors.WithMessage(writeFileAtomic(r.path, b), "unable to write user file")
+}
+
+// パスワードのbcryptハッシュを返す関数
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to hash password")
+	}
+	return string(hashedPassword), nil
+}
+
+// パスワードがハッシュと一致するかを確認する関数
+func checkPassword(password, hashedPassword string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+}
+
+// ユーザアカウントを登録するメソッド、ユーザファイルが設定されていないサーバでは登録できない
+// 認証の前に呼べるので、bcryptの計算を繰り返させないようLoginと同じIPアドレスごとの制限をかける
+func (s *server) Register(ctx context.Context, req *chat.RegisterRequest) (*chat.RegisterResponse, error) {
+	if s.draining.Load() {
+		return nil, status.Error(codes.Unavailable, "server is draining and not accepting new registrations")
+	}
+	if err := s.checkLogin(ctx); err != nil {
+		return nil, err
+	}
+	switch {
+	case s.Users == nil:
+		return nil, status.Error(codes.FailedPrecondition, "registration is disabled on this server")
+	case req.Name == "":
+		return nil, status.Error(codes.InvalidArgument, "name cannot be empty")
+	case len(req.Password) < minPasswordLength:
+		return nil, status.Errorf(codes.InvalidArgument, "password must be at least %d characters", minPasswordLength)
+	case len(req.Password) > maxPasswordBytes:
+		return nil, status.Errorf(codes.InvalidArgument, "password must be at most %d bytes", maxPasswordBytes)
+	// モデレータの名前は先に登録して権限を奪えないよう、運用者が-moderatorsに加える前に登録しておく
+	case s.Moderators[req.Name]:
+		return nil, status.Error(codes.PermissionDenied, "name is reserved")
+	}
+
+	switch err := s.Users.Register(req.Name, req.Password); err {
+	case nil:
+		// noop
+	case errUserExists:
+		return nil, status.Error(codes.AlreadyExists, "name is already registered")
+	default:
+		s.Log.Warn("failed to register", "name", req.Name, "err", err)
+		return nil, status.Error(codes.Internal, "unable to register user")
+	}
+
+	s.Log.Info("registered", "name", req.Name)
+
+	return new(chat.RegisterResponse), nil
+}
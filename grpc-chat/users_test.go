@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	chat "grpc-chat/protos"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRegister(t *testing.T) {
+	users, err := loadUsers(filepath.Join(t.TempDir(), "users.json"))
+	require.NoError(t, err)
+	ts := startServer(t, func(s *server) { s.Users = users })
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, tc := range []struct {
+		name, password string
+		code           codes.Code
+	}{
+		{"alice", "short", codes.InvalidArgument},
+		// bcryptは72バイトより長いパスワードを扱えない
+		{"alice", strings.Repeat("x", maxPasswordBytes+1), codes.InvalidArgument},
+		{"alice", strings.Repeat("x", maxPasswordBytes), codes.OK},
+		{"alice", "password", codes.AlreadyExists},
+	} {
+		_, err := cc.Register(ctx, &chat.RegisterRequest{Name: tc.name, Password: tc.password})
+		require.Equal(t, tc.code, status.Code(err), "password of %d bytes", len(tc.password))
+	}
+
+	_, err = cc.Login(ctx, &chat.LoginRequest{Name: "alice", Password: strings.Repeat("x", maxPasswordBytes)})
+	require.NoError(t, err)
+}
+
+func TestRegisterRestrictions(t *testing.T) {
+	users, err := loadUsers(filepath.Join(t.TempDir(), "users.json"))
+	require.NoError(t, err)
+	ts := startServer(t, func(s *server) {
+		s.Users = users
+		s.Moderators["mod"] = true
+		s.Limits = newRateLimits(0, 0, 0, 2)
+	})
+	cc := chat.NewChatClient(ts.conn(t))
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// モデレータの名前は誰も登録できない
+	_, err = cc.Register(ctx, &chat.RegisterRequest{Name: "mod", Password: "password"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Loginと同じ制限で数え、上限を超えるとbcryptを計算する前に拒否する
+	_, err = cc.Register(ctx, &chat.RegisterRequest{Name: "alice", Password: "password"})
+	require.NoError(t, err)
+	_, err = cc.Register(ctx, &chat.RegisterRequest{Name: "bob", Password: "password"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	ts.draining.Store(true)
+	_, err = cc.Register(ctx, &chat.RegisterRequest{Name: "carol", Password: "password"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestCheckUnknownUser(t *testing.T) {
+	users, err := loadUsers(filepath.Join(t.TempDir(), "users.json"))
+	require.NoError(t, err)
+	require.NoError(t, users.Register("alice", "password"))
+
+	require.NoError(t, users.Check("alice", "password"))
+	require.ErrorIs(t, users.Check("alice", "wrong"), errBadCredentials)
+	// 登録されていないユーザはダミーのハッシュと比べたうえで拒否する
+	require.ErrorIs(t, users.Check("bob", "password"), errBadCredentials)
+	require.ErrorIs(t, users.Check("bob", "not a registered user's password"), errBadCredentials)
+}